@@ -0,0 +1,183 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Clock of tao, real time by default, which can be replaced by a fake one in tests, see taotest.FakeClock
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	Sleep(d time.Duration)
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer of Clock, like time.Timer
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker of Clock, like time.Ticker
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+	Reset(d time.Duration)
+}
+
+var _ Clock = realClock{}
+
+// realClock of package time
+type realClock struct{}
+
+// Now of time
+func (realClock) Now() time.Time { return time.Now() }
+
+// Since of time
+func (realClock) Since(t time.Time) time.Duration { return time.Since(t) }
+
+// Sleep of time
+func (realClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// NewTimer of time
+func (realClock) NewTimer(d time.Duration) Timer { return realTimer{time.NewTimer(d)} }
+
+// NewTicker of time
+func (realClock) NewTicker(d time.Duration) Ticker { return realTicker{time.NewTicker(d)} }
+
+// realTimer of time.Timer
+type realTimer struct{ *time.Timer }
+
+// C of timer
+func (t realTimer) C() <-chan time.Time { return t.Timer.C }
+
+// realTicker of time.Ticker
+type realTicker struct{ *time.Ticker }
+
+// C of ticker
+func (t realTicker) C() <-chan time.Time { return t.Ticker.C }
+
+// clockHolder in atomic.Value, whose concrete type must be consistent
+type clockHolder struct{ Clock }
+
+// globalClock of universe
+var globalClock atomic.Value
+
+// SetClock of universe, used by tasks & pipelines without their own clocks, log timestamps, etc.
+// the real clock is restored if c is nil
+func SetClock(c Clock) {
+	if c == nil {
+		c = realClock{}
+	}
+	globalClock.Store(clockHolder{c})
+}
+
+// GetClock of universe
+func GetClock() Clock {
+	if h, ok := globalClock.Load().(clockHolder); ok {
+		return h.Clock
+	}
+	return realClock{}
+}
+
+// clockKey of context
+type clockKey struct{}
+
+// ContextWithClock for tasks run by ctx, see ClockOf
+func ContextWithClock(ctx context.Context, c Clock) context.Context {
+	return context.WithValue(ctx, clockKey{}, c)
+}
+
+// ClockOf ctx, which is set by tasks & pipelines with their own clocks, clock of universe if not set
+func ClockOf(ctx context.Context) Clock {
+	if ctx != nil {
+		if c, ok := ctx.Value(clockKey{}).(Clock); ok {
+			return c
+		}
+	}
+	return GetClock()
+}
+
+// WithTimeout of parent, whose deadline follows c instead of the real time
+func WithTimeout(c Clock, parent context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := c.(realClock); ok {
+		return context.WithTimeout(parent, d)
+	}
+
+	deadline := c.Now().Add(d)
+	if dl, ok := parent.Deadline(); ok && dl.Before(deadline) {
+		// parent is done earlier, whose deadline is in time of c as well
+		return context.WithCancel(parent)
+	}
+
+	ctx := &clockContext{Context: parent, deadline: deadline, done: make(chan struct{})}
+	stop := context.AfterFunc(parent, func() {
+		ctx.cancel(parent.Err())
+	})
+	timer := c.NewTimer(d)
+	go func() {
+		select {
+		case <-timer.C():
+			ctx.cancel(context.DeadlineExceeded)
+		case <-ctx.done:
+			timer.Stop()
+		}
+	}()
+	return ctx, func() {
+		stop()
+		ctx.cancel(context.Canceled)
+	}
+}
+
+// clockContext with deadline of Clock, values are looked up in parent
+type clockContext struct {
+	context.Context
+	deadline time.Time
+
+	mu   sync.Mutex
+	done chan struct{}
+	err  error
+}
+
+// cancel ctx with err once
+func (c *clockContext) cancel(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	close(c.done)
+}
+
+// Deadline of Clock
+func (c *clockContext) Deadline() (time.Time, bool) { return c.deadline, true }
+
+// Done of context
+func (c *clockContext) Done() <-chan struct{} { return c.done }
+
+// Err of context, DeadlineExceeded if done by the timer of Clock
+func (c *clockContext) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
@@ -0,0 +1,128 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stepClock moves a minute forward every Now, with real timers
+type stepClock struct {
+	realClock
+	now time.Time
+}
+
+func (c *stepClock) Now() time.Time {
+	c.now = c.now.Add(time.Minute)
+	return c.now
+}
+
+func (c *stepClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+func TestClock(t *testing.T) {
+	t.Run("Real", func(t *testing.T) {
+		c := GetClock()
+		assert.Equal(t, realClock{}, c)
+		assert.WithinDuration(t, time.Now(), c.Now(), time.Second)
+		assert.True(t, c.Since(c.Now()) < time.Second)
+		c.Sleep(time.Millisecond)
+
+		timer := c.NewTimer(time.Millisecond)
+		<-timer.C()
+		assert.False(t, timer.Stop())
+		assert.False(t, timer.Reset(time.Hour))
+		assert.True(t, timer.Stop())
+
+		ticker := c.NewTicker(time.Millisecond)
+		<-ticker.C()
+		ticker.Reset(time.Hour)
+		ticker.Stop()
+
+		ctx, cancel := WithTimeout(c, context.Background(), time.Hour)
+		defer cancel()
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), deadline, time.Second)
+	})
+
+	t.Run("SetClock", func(t *testing.T) {
+		c := &stepClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+		SetClock(c)
+		defer SetClock(nil)
+		assert.Equal(t, c, GetClock())
+		assert.Equal(t, c, ClockOf(nil))
+		assert.Equal(t, c, ClockOf(context.Background()))
+
+		other := new(stepClock)
+		assert.Equal(t, other, ClockOf(ContextWithClock(context.Background(), other)))
+	})
+
+	t.Run("WithTimeout", func(t *testing.T) {
+		c := new(stepClock)
+		ctx, cancel := WithTimeout(c, context.Background(), 10*time.Millisecond)
+		defer cancel()
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.Equal(t, c.now.Add(10*time.Millisecond), deadline)
+		<-ctx.Done()
+		assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+
+		ctx, cancel = WithTimeout(c, context.Background(), time.Hour)
+		cancel()
+		<-ctx.Done()
+		assert.Equal(t, context.Canceled, ctx.Err())
+
+		// parent is done earlier
+		parent, cancelParent := WithTimeout(c, context.Background(), time.Millisecond)
+		defer cancelParent()
+		ctx, cancel = WithTimeout(c, parent, 365*24*time.Hour)
+		defer cancel()
+		deadline, _ = ctx.Deadline()
+		assert.Equal(t, func() time.Time { d, _ := parent.Deadline(); return d }(), deadline)
+		<-ctx.Done()
+		assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+	})
+
+	t.Run("Task", func(t *testing.T) {
+		taskClock := new(stepClock)
+		var got Clock
+		task := NewTask("clock", func(ctx context.Context, param Parameter) (Parameter, error) {
+			got = ClockOf(ctx)
+			return param, nil
+		}, SetTaskClock(taskClock))
+		assert.Nil(t, task.Run(context.Background(), nil))
+		assert.Equal(t, taskClock, got)
+
+		pipeClock := new(stepClock)
+		pipeTask := NewPipeTask(NewTask("clock", func(ctx context.Context, param Parameter) (Parameter, error) {
+			got = ClockOf(ctx)
+			return param, nil
+		}))
+		p := NewPipeline("clock", SetPipelineClock(pipeClock))
+		assert.Nil(t, p.Register(pipeTask))
+		assert.Nil(t, p.Run(context.Background(), nil))
+		assert.Equal(t, pipeClock, got)
+		// duration by clock of pipeline
+		elapsed, running := pipeTask.elapsed()
+		assert.False(t, running)
+		assert.Equal(t, time.Minute, elapsed)
+	})
+}
@@ -70,8 +70,9 @@ const ConfigKey = "tao"
 
 // taoConfig implements Config
 type taoConfig struct {
-	Log    *Log    `json:"log"`
-	Banner *Banner `json:"banner"`
+	Log    *Log         `json:"log"`
+	Banner *Banner      `json:"banner"`
+	Error  *ErrorConfig `json:"error,omitempty"`
 }
 
 // Banner config
@@ -125,6 +126,17 @@ func (t *taoConfig) ValidSelf() {
 			if t.Log.Path == "" {
 				t.Log.Path = defaultTao.Log.Path
 			}
+			if r := t.Log.Rotate; r != nil {
+				if r.MaxSize < 0 {
+					r.MaxSize = 0
+				}
+				if r.MaxBackups < 0 {
+					r.MaxBackups = 0
+				}
+				if r.MaxAge < 0 {
+					r.MaxAge = 0
+				}
+			}
 		}
 		if t.Log.Flag == 0 {
 			t.Log.Flag = defaultTao.Log.Flag
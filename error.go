@@ -15,7 +15,9 @@
 package tao
 
 import (
+	"context"
 	"fmt"
+	"runtime"
 	"sync"
 )
 
@@ -30,30 +32,41 @@ type ErrorUnWrapper interface {
 }
 
 var _ ErrorUnWrapper = (*errorWrapped)(nil)
+var _ ErrorStacker = (*errorWrapped)(nil)
+var _ fmt.Formatter = (*errorWrapped)(nil)
 
 // errorWrapped implements ErrorUnWrapper
 type errorWrapped struct {
 	msg string
 	err error
+
+	// own message without e
+	own   string
+	stack stack
 }
 
 // NewErrorWrapped constructor of errorWrapped
 func NewErrorWrapped(format string, e error) ErrorUnWrapper {
 	if e != nil {
-		return &errorWrapped{format + errSplit + e.Error(), e}
+		return &errorWrapped{format + errSplit + e.Error(), e, format, callers(2)}
 	}
-	return &errorWrapped{format, nil}
+	return &errorWrapped{format, nil, format, callers(2)}
 }
 
 // Error string
 func (e *errorWrapped) Error() string { return e.msg }
 
+// Frames of stack
+func (e *errorWrapped) Frames() []runtime.Frame { return e.stack.frames() }
+
+// Format with stack & causes by %+v
+func (e *errorWrapped) Format(s fmt.State, verb rune) {
+	formatError(s, verb, e, e.own, e.Frames(), e.err)
+}
+
 // Unwrap e self
 func (e *errorWrapped) Unwrap() error { return e.err }
 
-// Is a same error
-func (e *errorWrapped) Is(err error) bool { return e.Error() == err.Error() }
-
 // ErrorTao extension of error, wrap of error
 type ErrorTao interface {
 	error
@@ -63,19 +76,24 @@ type ErrorTao interface {
 }
 
 var _ ErrorTao = (*errorTao)(nil)
-var _ ErrorUnWrapper = (*errorTao)(nil)
+var _ ErrorStacker = (*errorTao)(nil)
+var _ ErrorDetailer = (*errorTao)(nil)
+var _ fmt.Formatter = (*errorTao)(nil)
 
 // errorTao with code & message
 // code for computer
 // message for user
-// implements ErrorTao & ErrorUnWrapper
+// implements ErrorTao, causes wrapped are unwrapped by Unwrap() []error
 type errorTao struct {
 	mu sync.RWMutex
 
 	code    string
 	message string
+	args    []interface{}
+	details []interface{}
 
-	cause ErrorUnWrapper
+	causes []error
+	stack  stack
 }
 
 // NewError constructor of ErrorTao
@@ -83,7 +101,21 @@ func NewError(code, message string, a ...interface{}) ErrorTao {
 	return &errorTao{
 		code:    code,
 		message: fmt.Sprintf(message, a...),
+		args:    a,
+		stack:   callers(2),
+	}
+}
+
+// canceledError of ctx, which wraps ctx.Err() to match context.Canceled or context.DeadlineExceeded
+func canceledError(ctx context.Context, message string, a ...interface{}) ErrorTao {
+	e := &errorTao{
+		code:    ContextCanceled,
+		message: fmt.Sprintf(message, a...),
+		args:    a,
+		stack:   callers(2),
 	}
+	e.Wrap(ctx.Err())
+	return e
 }
 
 // Code string
@@ -97,44 +129,99 @@ func (e *errorTao) Code() string {
 func (e *errorTao) Error() string {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
-	if e.cause != nil {
-		return fmt.Sprintf("<%s>%s", e.code, e.message+errSplit+e.cause.Error())
+	if len(e.causes) == 0 {
+		return e.message
 	}
-	return e.message
+	// latest cause first
+	msg := fmt.Sprintf("<%s>%s", e.code, e.message)
+	for i := len(e.causes) - 1; i >= 0; i-- {
+		msg += errSplit + e.causes[i].Error()
+	}
+	return msg
 }
 
-// Wrap error into errorTao
+// Wrap error into errorTao, each error wrapped is kept as it is
 func (e *errorTao) Wrap(err error) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	if err == nil {
 		return
 	}
-	// wrap cause & err
-	if e.cause != nil {
-		e.cause = NewErrorWrapped(err.Error(), e.cause)
-		return
-	}
-	// init cause
-	if cause, ok := err.(ErrorUnWrapper); ok {
-		e.cause = cause
-	} else {
-		e.cause = NewErrorWrapped(err.Error(), nil)
-	}
+	e.causes = append(e.causes, err)
 }
 
-// Cause of error
+// Cause of error, the first one wrapped
 func (e *errorTao) Cause() error {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
-	return e.cause
+	if len(e.causes) == 0 {
+		return nil
+	}
+	return e.causes[0]
 }
 
-// Unwrap e self
-func (e *errorTao) Unwrap() error {
+// Details attached
+func (e *errorTao) Details() []interface{} {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
-	return e.cause
+	return append([]interface{}(nil), e.details...)
+}
+
+// Unwrap to causes, latest first
+func (e *errorTao) Unwrap() []error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	causes := make([]error, 0, len(e.causes))
+	for i := len(e.causes) - 1; i >= 0; i-- {
+		causes = append(causes, e.causes[i])
+	}
+	return causes
+}
+
+// Frames of stack
+func (e *errorTao) Frames() []runtime.Frame { return e.stack.frames() }
+
+// Format with stack & causes by %+v
+func (e *errorTao) Format(s fmt.State, verb rune) {
+	formatError(s, verb, e, fmt.Sprintf("<%s>%s", e.Code(), e.message), e.Frames(), e.Unwrap()...)
+}
+
+// Is an ErrorTao with same code
+func (e *errorTao) Is(target error) bool { return isCode(e, target) }
+
+// isCode of e & target
+func isCode(e ErrorTao, target error) bool {
+	t, ok := target.(ErrorTao)
+	return ok && t.Code() == e.Code()
+}
+
+// HasCode of err or any error in its chain & trees
+func HasCode(err error, code string) bool {
+	return !walkError(err, func(e error) bool {
+		t, ok := e.(ErrorTao)
+		return !ok || t.Code() != code
+	})
+}
+
+// walkError in depth first order until fn returns false
+func walkError(err error, fn func(e error) bool) bool {
+	if err == nil {
+		return true
+	}
+	if !fn(err) {
+		return false
+	}
+	switch u := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, e := range u.Unwrap() {
+			if !walkError(e, fn) {
+				return false
+			}
+		}
+	case interface{ Unwrap() error }:
+		return walkError(u.Unwrap(), fn)
+	}
+	return true
 }
 
 /**
@@ -143,6 +230,7 @@ ErrorCode
 const (
 	Unknown         = "Unknown"
 	ParamInvalid    = "ParamInvalid"
+	ParamImmutable  = "ParamImmutable"
 	ContextCanceled = "ContextCanceled"
 	DuplicateCall   = "DuplicateCall"
 	TaskRunTwice    = "TaskRunTwice"
@@ -151,4 +239,7 @@ const (
 	TaskRunning     = "TaskRunning"
 	ConfigNotFound  = "ConfigNotFound"
 	UniverseNotInit = "UniverseNotInit"
+
+	DependencyNotFound = "DependencyNotFound"
+	DependencyCycle    = "DependencyCycle"
 )
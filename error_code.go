@@ -0,0 +1,265 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrorCode with metadata, registered by units
+type ErrorCode struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	// Severity of error, ERROR by default unless SeveritySet
+	Severity  LogLevel `json:"severity"`
+	Retryable bool     `json:"retryable"`
+	// HTTPStatus of error, 500 by default
+	HTTPStatus int `json:"http_status"`
+	// GRPCCode of error, same as codes.Code of grpc, 2(Unknown) by default
+	GRPCCode uint32 `json:"grpc_code"`
+	// SeveritySet keeps zero Severity as DEBUG
+	SeveritySet bool `json:"-"`
+}
+
+// grpc codes
+const (
+	grpcCanceled           = 1
+	grpcUnknown            = 2
+	grpcInvalidArgument    = 3
+	grpcNotFound           = 5
+	grpcAlreadyExists      = 6
+	grpcFailedPrecondition = 9
+	grpcAborted            = 10
+	grpcInternal           = 13
+)
+
+// errorCodeRegistry of ErrorCode
+type errorCodeRegistry struct {
+	mu sync.RWMutex
+
+	codes      map[string]ErrorCode
+	duplicates map[string]struct{}
+}
+
+// newErrorCodeRegistry with codes of tao
+func newErrorCodeRegistry() *errorCodeRegistry {
+	r := &errorCodeRegistry{
+		codes:      make(map[string]ErrorCode),
+		duplicates: make(map[string]struct{}),
+	}
+	_ = r.register(
+		ErrorCode{Unknown, "unknown error", ERROR, false, http.StatusInternalServerError, grpcUnknown, true},
+		ErrorCode{ParamInvalid, "parameter is invalid", WARNING, false, http.StatusBadRequest, grpcInvalidArgument, true},
+		ErrorCode{ParamImmutable, "parameter is frozen", WARNING, false, http.StatusConflict, grpcFailedPrecondition, true},
+		ErrorCode{ContextCanceled, "context has been canceled", WARNING, false, 499, grpcCanceled, true},
+		ErrorCode{DuplicateCall, "called more than once", WARNING, false, http.StatusConflict, grpcAlreadyExists, true},
+		ErrorCode{TaskRunTwice, "task run twice", WARNING, false, http.StatusConflict, grpcFailedPrecondition, true},
+		ErrorCode{TaskCloseTwice, "task closed twice", WARNING, false, http.StatusConflict, grpcFailedPrecondition, true},
+		ErrorCode{TaskClosed, "task has been closed", WARNING, false, http.StatusConflict, grpcFailedPrecondition, true},
+		ErrorCode{TaskRunning, "task is running", WARNING, true, http.StatusConflict, grpcAborted, true},
+		ErrorCode{ConfigNotFound, "config not found", ERROR, false, http.StatusNotFound, grpcNotFound, true},
+		ErrorCode{UniverseNotInit, "universe not initialized", ERROR, false, http.StatusInternalServerError, grpcFailedPrecondition, true},
+		ErrorCode{DependencyNotFound, "dependency of task not found", ERROR, false, http.StatusInternalServerError, grpcInternal, true},
+		ErrorCode{DependencyCycle, "dependency cycle among tasks", ERROR, false, http.StatusInternalServerError, grpcInternal, true},
+	)
+	return r
+}
+
+// register codes, duplicated codes are recorded & keep the first one
+func (r *errorCodeRegistry) register(codes ...ErrorCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var duplicates []string
+	for _, c := range codes {
+		if c.Code == "" {
+			return NewError(ParamInvalid, "error: code is empty")
+		}
+		if _, ok := r.codes[c.Code]; ok {
+			r.duplicates[c.Code] = struct{}{}
+			duplicates = append(duplicates, c.Code)
+			continue
+		}
+		if !c.SeveritySet && c.Severity == DEBUG {
+			c.Severity = ERROR
+		}
+		c.SeveritySet = true
+		if c.HTTPStatus == 0 {
+			c.HTTPStatus = http.StatusInternalServerError
+		}
+		if c.GRPCCode == 0 {
+			c.GRPCCode = grpcUnknown
+		}
+		r.codes[c.Code] = c
+	}
+	if len(duplicates) != 0 {
+		return NewError(DuplicateCall, "error: codes %q registered more than once", duplicates)
+	}
+	return nil
+}
+
+// lookup code
+func (r *errorCodeRegistry) lookup(code string) (ErrorCode, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.codes[code]
+	return c, ok
+}
+
+// check duplicated codes
+func (r *errorCodeRegistry) check() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.duplicates) == 0 {
+		return nil
+	}
+	duplicates := make([]string, 0, len(r.duplicates))
+	for code := range r.duplicates {
+		duplicates = append(duplicates, code)
+	}
+	sort.Strings(duplicates)
+	return NewError(DuplicateCall, "error: codes %q registered more than once", duplicates)
+}
+
+// errorCodes of tao & units
+var errorCodes = newErrorCodeRegistry()
+
+// RegisterErrorCode of units, usually called in init of unit
+// duplicated codes are also detected when tao runs
+func RegisterErrorCode(codes ...ErrorCode) error {
+	return errorCodes.register(codes...)
+}
+
+// LookupErrorCode registered before
+func LookupErrorCode(code string) (ErrorCode, bool) {
+	return errorCodes.lookup(code)
+}
+
+// ErrorCodeOf err, the first code of ErrorTao in its chain & trees except Unknown
+// Unknown if not found
+func ErrorCodeOf(err error) ErrorCode {
+	code := Unknown
+	walkError(err, func(e error) bool {
+		if t, ok := e.(ErrorTao); ok && t.Code() != Unknown {
+			code = t.Code()
+			return false
+		}
+		return true
+	})
+	if c, ok := LookupErrorCode(code); ok {
+		return c
+	}
+	c, _ := LookupErrorCode(Unknown)
+	c.Code = code
+	return c
+}
+
+// HTTPStatus of err
+func HTTPStatus(err error) int {
+	return ErrorCodeOf(err).HTTPStatus
+}
+
+// GRPCCode of err
+func GRPCCode(err error) uint32 {
+	return ErrorCodeOf(err).GRPCCode
+}
+
+// ErrorEnvelope of API error in JSON
+type ErrorEnvelope struct {
+	Code      string        `json:"code"`
+	Message   string        `json:"message"`
+	Retryable bool          `json:"retryable,omitempty"`
+	Causes    []string      `json:"causes,omitempty"`
+	Details   []interface{} `json:"details,omitempty"`
+}
+
+// NewErrorEnvelope of err, causes & details are collected from its chain & trees
+func NewErrorEnvelope(err error) *ErrorEnvelope {
+	code := ErrorCodeOf(err)
+	envelope := &ErrorEnvelope{
+		Code:      code.Code,
+		Retryable: code.Retryable,
+	}
+	walkError(err, func(e error) bool {
+		if msg := errorMessage(e); msg != "" {
+			if e == err {
+				envelope.Message = msg
+			} else {
+				envelope.Causes = append(envelope.Causes, msg)
+			}
+		}
+		if d, ok := e.(ErrorDetailer); ok {
+			envelope.Details = append(envelope.Details, d.Details()...)
+		}
+		return true
+	})
+	if envelope.Message == "" {
+		// transparent wrappers, e.g. MultiError
+		if len(envelope.Causes) != 0 {
+			envelope.Message, envelope.Causes = envelope.Causes[0], envelope.Causes[1:]
+		} else {
+			envelope.Message = code.Description
+		}
+	}
+	if len(envelope.Causes) == 0 {
+		envelope.Causes = nil
+	}
+	return envelope
+}
+
+// RenderError to HTTP status & body of JSON, e.g. {"error":{"code":"ParamInvalid","message":"..."}}
+func RenderError(err error) (int, []byte) {
+	return RenderLocalizedError(err, "")
+}
+
+// RenderLocalizedError with message in locale, see LocalizeError
+func RenderLocalizedError(err error, locale string) (int, []byte) {
+	envelope := NewErrorEnvelope(err)
+	if msg, ok := localizeError(err, locale); ok {
+		envelope.Message = msg
+	}
+	body, e := json.Marshal(map[string]*ErrorEnvelope{"error": envelope})
+	if e != nil {
+		// details can't be marshaled
+		envelope.Details = nil
+		body, _ = json.Marshal(map[string]*ErrorEnvelope{"error": envelope})
+	}
+	return HTTPStatus(err), body
+}
+
+// errorMessage of e itself without its causes
+func errorMessage(err error) string {
+	switch e := err.(type) {
+	case *errorTao:
+		return e.message
+	case *errorWrapped:
+		return e.own
+	case *TaskError, MultiError:
+		return ""
+	case interface{ Unwrap() error }:
+		// e.g. fmt.Errorf("msg: %w", err)
+		msg := err.Error()
+		if cause := e.Unwrap(); cause != nil {
+			msg = strings.TrimRight(strings.TrimSuffix(msg, cause.Error()), ": ")
+		}
+		return msg
+	default:
+		return err.Error()
+	}
+}
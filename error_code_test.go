@@ -0,0 +1,117 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// detailedError for test
+type detailedError struct {
+	error
+	details []interface{}
+}
+
+func (e *detailedError) Details() []interface{} { return e.details }
+
+func (e *detailedError) Unwrap() error { return e.error }
+
+func TestErrorCode(t *testing.T) {
+	t.Run("Register", func(t *testing.T) {
+		assert.Nil(t, RegisterErrorCode(ErrorCode{Code: "test.Throttled", Description: "too many requests", Retryable: true, HTTPStatus: http.StatusTooManyRequests, GRPCCode: 8}))
+
+		c, ok := LookupErrorCode("test.Throttled")
+		assert.True(t, ok)
+		assert.Equal(t, ERROR, c.Severity)
+		assert.Equal(t, uint32(8), c.GRPCCode)
+
+		assert.Nil(t, RegisterErrorCode(ErrorCode{Code: "test.Ignored", Severity: DEBUG, SeveritySet: true}))
+		c, ok = LookupErrorCode("test.Ignored")
+		assert.True(t, ok)
+		assert.Equal(t, DEBUG, c.Severity)
+
+		c, ok = LookupErrorCode(ParamInvalid)
+		assert.True(t, ok)
+		assert.Equal(t, http.StatusBadRequest, c.HTTPStatus)
+
+		_, ok = LookupErrorCode("test.NotRegistered")
+		assert.False(t, ok)
+		assert.NotNil(t, RegisterErrorCode(ErrorCode{}))
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		r := newErrorCodeRegistry()
+		assert.Nil(t, r.check())
+		assert.Nil(t, r.register(ErrorCode{Code: "unitA.Timeout", HTTPStatus: http.StatusGatewayTimeout}))
+
+		err := r.register(ErrorCode{Code: "unitA.Timeout"}, ErrorCode{Code: TaskClosed})
+		assert.True(t, HasCode(err, DuplicateCall))
+		c, _ := r.lookup("unitA.Timeout")
+		assert.Equal(t, http.StatusGatewayTimeout, c.HTTPStatus)
+
+		err = r.check()
+		assert.True(t, HasCode(err, DuplicateCall))
+		assert.Contains(t, err.Error(), `["TaskClosed" "unitA.Timeout"]`)
+	})
+
+	t.Run("Status", func(t *testing.T) {
+		assert.Equal(t, http.StatusInternalServerError, HTTPStatus(errors.New("std")))
+		assert.Equal(t, uint32(2), GRPCCode(nil))
+
+		e := NewMultiError(Unknown)
+		e.Append("a", NewError(ParamInvalid, "invalid"))
+		err := NewErrorWrapped("tao: fail to run", e)
+		assert.Equal(t, http.StatusBadRequest, HTTPStatus(err))
+		assert.Equal(t, uint32(3), GRPCCode(err))
+		assert.Equal(t, &ErrorEnvelope{Code: ParamInvalid, Message: "tao: fail to run", Causes: []string{"invalid"}}, NewErrorEnvelope(err))
+		assert.Equal(t, &ErrorEnvelope{Code: Unknown, Message: "unknown error"}, NewErrorEnvelope(NewMultiError(Unknown)))
+
+		c := ErrorCodeOf(NewError("test.Unregistered", "unregistered"))
+		assert.Equal(t, "test.Unregistered", c.Code)
+		assert.Equal(t, http.StatusInternalServerError, c.HTTPStatus)
+	})
+
+	t.Run("Envelope", func(t *testing.T) {
+		root := &detailedError{errors.New("name is empty"), []interface{}{map[string]string{"field": "name"}}}
+		e := NewError(ParamInvalid, "invalid request")
+		e.Wrap(root)
+		err := fmt.Errorf("handler: %w", e)
+
+		envelope := NewErrorEnvelope(err)
+		assert.Equal(t, &ErrorEnvelope{
+			Code:    ParamInvalid,
+			Message: "handler",
+			Causes:  []string{"invalid request", "name is empty"},
+			Details: []interface{}{map[string]string{"field": "name"}},
+		}, envelope)
+
+		status, body := RenderError(e)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.JSONEq(t, `{"error":{"code":"ParamInvalid","message":"invalid request","causes":["name is empty"],"details":[{"field":"name"}]}}`, string(body))
+
+		// details can't be marshaled
+		status, body = RenderError(&detailedError{NewError(TaskRunning, "running"), []interface{}{func() {}}})
+		assert.Equal(t, http.StatusConflict, status)
+		envelope = new(ErrorEnvelope)
+		assert.Nil(t, json.Unmarshal(body[len(`{"error":`):len(body)-1], envelope))
+		assert.Equal(t, &ErrorEnvelope{Code: TaskRunning, Message: "running", Retryable: true}, envelope)
+	})
+}
@@ -0,0 +1,132 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ErrorDetailer error with typed details
+type ErrorDetailer interface {
+	error
+	Details() []interface{}
+}
+
+var _ ErrorDetailer = (*errorDetailed)(nil)
+var _ ErrorUnWrapper = (*errorDetailed)(nil)
+
+// errorDetailed wraps other errors with details attached
+type errorDetailed struct {
+	err     error
+	details []interface{}
+}
+
+// Error string of err
+func (e *errorDetailed) Error() string { return e.err.Error() }
+
+// Unwrap err
+func (e *errorDetailed) Unwrap() error { return e.err }
+
+// Details attached
+func (e *errorDetailed) Details() []interface{} { return e.details }
+
+// WithDetails of err with typed details attached, e.g. FieldViolation, RetryInfo, ResourceInfo
+// err is never modified, so errors shared like package variables are safe,
+// ErrorTao & MultiError are copied with details appended, other errors are wrapped
+func WithDetails(err error, details ...interface{}) error {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *errorTao:
+		e.mu.RLock()
+		defer e.mu.RUnlock()
+		return &errorTao{
+			code:    e.code,
+			message: e.message,
+			args:    e.args,
+			details: appendDetails(e.details, details),
+			causes:  append([]error(nil), e.causes...),
+			stack:   e.stack,
+		}
+	case *multiError:
+		e.mu.RLock()
+		defer e.mu.RUnlock()
+		return &multiError{
+			code:    e.code,
+			errs:    append([]error(nil), e.errs...),
+			details: appendDetails(e.details, details),
+		}
+	default:
+		return &errorDetailed{err: err, details: details}
+	}
+}
+
+// appendDetails to a new slice
+func appendDetails(origin, details []interface{}) []interface{} {
+	return append(append(make([]interface{}, 0, len(origin)+len(details)), origin...), details...)
+}
+
+// FieldViolation detail of an invalid field in request
+type FieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// RetryInfo detail of when to retry
+type RetryInfo struct {
+	RetryAfter time.Duration `json:"retry_after"`
+}
+
+// MarshalJSON with retry_after in string, e.g. 1.5s
+func (r RetryInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"retry_after": r.RetryAfter.String()})
+}
+
+// UnmarshalJSON with retry_after in string
+func (r *RetryInfo) UnmarshalJSON(data []byte) error {
+	var v struct {
+		RetryAfter string `json:"retry_after"`
+	}
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+	r.RetryAfter, err = time.ParseDuration(v.RetryAfter)
+	return err
+}
+
+// ResourceInfo detail of the resource accessed
+type ResourceInfo struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// ErrorDetail of type T, the first one attached to err or any error in its chain & trees
+func ErrorDetail[T any](err error) (detail T, ok bool) {
+	walkError(err, func(e error) bool {
+		d, is := e.(ErrorDetailer)
+		if !is {
+			return true
+		}
+		for _, v := range d.Details() {
+			if detail, ok = v.(T); ok {
+				return false
+			}
+		}
+		return true
+	})
+	return
+}
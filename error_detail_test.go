@@ -0,0 +1,94 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorDetail(t *testing.T) {
+	t.Run("Details", func(t *testing.T) {
+		shared := NewError(ParamInvalid, "param: %q invalid", "name")
+		e := WithDetails(shared, FieldViolation{Field: "name", Description: "name is empty"})
+		e = WithDetails(e, ResourceInfo{Type: "user", Name: "u1"})
+		assert.NotSame(t, shared, e)
+		assert.Len(t, e.(ErrorDetailer).Details(), 2)
+		assert.Equal(t, shared.Error(), e.Error())
+		assert.Equal(t, ParamInvalid, e.(ErrorTao).Code())
+		// shared error is never modified
+		assert.Len(t, shared.(ErrorDetailer).Details(), 0)
+		assert.Len(t, WithDetails(shared, RetryInfo{}).(ErrorDetailer).Details(), 1)
+
+		emptyMulti := NewMultiError(Unknown)
+		multi := WithDetails(emptyMulti, RetryInfo{RetryAfter: time.Second}).(MultiError)
+		assert.NotSame(t, emptyMulti, multi)
+		assert.Len(t, emptyMulti.(ErrorDetailer).Details(), 0)
+		multi.Wrap(e)
+		assert.Len(t, emptyMulti.Errors(), 0)
+		err := fmt.Errorf("handler: %w", multi)
+
+		violation, ok := ErrorDetail[FieldViolation](err)
+		assert.True(t, ok)
+		assert.Equal(t, "name", violation.Field)
+		retry, ok := ErrorDetail[RetryInfo](err)
+		assert.True(t, ok)
+		assert.Equal(t, time.Second, retry.RetryAfter)
+		_, ok = ErrorDetail[*ResourceInfo](err)
+		assert.False(t, ok)
+		_, ok = ErrorDetail[RetryInfo](nil)
+		assert.False(t, ok)
+
+		status, body := RenderError(err)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.JSONEq(t, `{"error":{"code":"ParamInvalid","message":"handler","causes":["param: \"name\" invalid"],
+"details":[{"retry_after":"1s"},{"field":"name","description":"name is empty"},{"type":"user","name":"u1"}]}}`, string(body))
+	})
+
+	t.Run("WithDetails", func(t *testing.T) {
+		assert.Nil(t, WithDetails(nil, RetryInfo{}))
+
+		std := errors.New("std")
+		err := WithDetails(std, ResourceInfo{Type: "user", Name: "u1"})
+		assert.Equal(t, "std", err.Error())
+		assert.True(t, errors.Is(err, std))
+		resource, ok := ErrorDetail[ResourceInfo](err)
+		assert.True(t, ok)
+		assert.Equal(t, "u1", resource.Name)
+
+		envelope := NewErrorEnvelope(NewErrorWrapped("handler", err))
+		assert.Equal(t, "handler", envelope.Message)
+		assert.Equal(t, []string{"std"}, envelope.Causes)
+		assert.Equal(t, []interface{}{ResourceInfo{Type: "user", Name: "u1"}}, envelope.Details)
+	})
+
+	t.Run("RetryInfo", func(t *testing.T) {
+		data, err := json.Marshal(RetryInfo{RetryAfter: 1500 * time.Millisecond})
+		assert.Nil(t, err)
+		assert.Equal(t, `{"retry_after":"1.5s"}`, string(data))
+
+		r := new(RetryInfo)
+		assert.Nil(t, json.Unmarshal(data, r))
+		assert.Equal(t, 1500*time.Millisecond, r.RetryAfter)
+		assert.NotNil(t, json.Unmarshal([]byte(`{"retry_after":"soon"}`), r))
+		assert.NotNil(t, json.Unmarshal([]byte(`[]`), r))
+	})
+}
@@ -0,0 +1,162 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrorMessageData of message template, e.g. "参数 {{index .Args 0}} 无效"
+type ErrorMessageData struct {
+	Code string
+	// Message formatted by NewError
+	Message string
+	// Args of NewError
+	Args    []interface{}
+	Details []interface{}
+}
+
+// errorCatalogs of locales, locale -> code -> template
+var errorCatalogs = struct {
+	sync.RWMutex
+	m map[string]map[string]*template.Template
+}{m: make(map[string]map[string]*template.Template)}
+
+// SetErrorMessages of locale, code -> text/template of ErrorMessageData
+// messages set before are replaced by code
+func SetErrorMessages(locale string, messages map[string]string) error {
+	locale = normalizeLocale(locale)
+	if locale == "" {
+		return NewError(ParamInvalid, "error: locale is empty")
+	}
+
+	catalog := make(map[string]*template.Template, len(messages))
+	for code, text := range messages {
+		tmpl, err := template.New(code).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return NewErrorWrapped("error: fail to parse message of "+code, err)
+		}
+		catalog[code] = tmpl
+	}
+
+	errorCatalogs.Lock()
+	defer errorCatalogs.Unlock()
+	if errorCatalogs.m[locale] == nil {
+		errorCatalogs.m[locale] = catalog
+		return nil
+	}
+	for code, tmpl := range catalog {
+		errorCatalogs.m[locale][code] = tmpl
+	}
+	return nil
+}
+
+// LoadErrorMessages of locale from yaml or json file of code -> template
+func LoadErrorMessages(locale, file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return NewErrorWrapped("error: fail to read messages file", err)
+	}
+
+	messages := make(map[string]string)
+	switch t := path.Ext(file); t {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &messages)
+	case ".json":
+		err = json.Unmarshal(data, &messages)
+	default:
+		return NewError(ParamInvalid, "error: %s file not supported", t)
+	}
+	if err != nil {
+		return NewErrorWrapped("error: fail to unmarshal messages of "+locale, err)
+	}
+	return SetErrorMessages(locale, messages)
+}
+
+// normalizeLocale like zh-cn, the first one if in form of Accept-Language
+func normalizeLocale(locale string) string {
+	if i := strings.IndexAny(locale, ",;"); i >= 0 {
+		locale = locale[:i]
+	}
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// errorTemplate of code in locale, falls back to parent locale, e.g. zh-hans-cn -> zh-hans -> zh
+func errorTemplate(locale, code string) *template.Template {
+	errorCatalogs.RLock()
+	defer errorCatalogs.RUnlock()
+	for locale = normalizeLocale(locale); locale != ""; {
+		if tmpl, ok := errorCatalogs.m[locale][code]; ok {
+			return tmpl
+		}
+		i := strings.LastIndexByte(locale, '-')
+		if i < 0 {
+			break
+		}
+		locale = locale[:i]
+	}
+	return nil
+}
+
+// LocalizeError message of err in locale, e.g. zh-CN or Accept-Language of request
+// the first ErrorTao in chain & trees with message template of its code is rendered,
+// message of NewErrorEnvelope if not found
+func LocalizeError(err error, locale string) string {
+	if msg, ok := localizeError(err, locale); ok {
+		return msg
+	}
+	return NewErrorEnvelope(err).Message
+}
+
+// localizeError of err in locale
+func localizeError(err error, locale string) (msg string, ok bool) {
+	if locale == "" {
+		return "", false
+	}
+	walkError(err, func(e error) bool {
+		t, is := e.(ErrorTao)
+		if !is {
+			return true
+		}
+		tmpl := errorTemplate(locale, t.Code())
+		if tmpl == nil {
+			return true
+		}
+		data := ErrorMessageData{
+			Code:    t.Code(),
+			Message: errorMessage(e),
+		}
+		if d, is := e.(ErrorDetailer); is {
+			data.Details = d.Details()
+		}
+		if et, is := e.(*errorTao); is {
+			data.Args = et.args
+		}
+		buf := new(strings.Builder)
+		if tmpl.Execute(buf, data) != nil {
+			return true
+		}
+		msg, ok = buf.String(), true
+		return false
+	})
+	return
+}
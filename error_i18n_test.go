@@ -0,0 +1,75 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorMessages(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := filepath.Join(dir, "errors.zh.yaml")
+	assert.Nil(t, os.WriteFile(yamlFile, []byte(`
+test.NotFound: "未找到资源 {{index .Args 0}}"
+test.Invalid: "字段 {{with index .Details 0}}{{.Field}}{{end}} 无效"
+`), 0666))
+	jsonFile := filepath.Join(dir, "errors.en.json")
+	assert.Nil(t, os.WriteFile(jsonFile, []byte(`{"test.NotFound": "resource {{index .Args 0}} not found ({{.Code}})"}`), 0666))
+
+	t.Run("Load", func(t *testing.T) {
+		assert.Nil(t, LoadErrorMessages("zh", yamlFile))
+		assert.Nil(t, LoadErrorMessages("en", jsonFile))
+
+		assert.NotNil(t, LoadErrorMessages("en", filepath.Join(dir, "none.yaml")))
+		assert.NotNil(t, LoadErrorMessages("en", filepath.Join(dir, "errors.toml")))
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, "bad.json"), []byte(`{`), 0666))
+		assert.NotNil(t, LoadErrorMessages("en", filepath.Join(dir, "bad.json")))
+
+		assert.NotNil(t, SetErrorMessages("", map[string]string{}))
+		assert.NotNil(t, SetErrorMessages("en", map[string]string{"test.Bad": "{{"}))
+	})
+
+	t.Run("Localize", func(t *testing.T) {
+		notFound := NewError("test.NotFound", "resource %s not found", "u1")
+		assert.Equal(t, "未找到资源 u1", LocalizeError(notFound, "zh-CN"))
+		assert.Equal(t, "未找到资源 u1", LocalizeError(notFound, "zh_Hans_CN,en;q=0.8"))
+		assert.Equal(t, "resource u1 not found (test.NotFound)", LocalizeError(notFound, "en-US"))
+		// fall back to message
+		assert.Equal(t, "resource u1 not found", LocalizeError(notFound, "fr"))
+		assert.Equal(t, "resource u1 not found", LocalizeError(notFound, ""))
+
+		invalid := WithDetails(NewError("test.Invalid", "field invalid"), FieldViolation{Field: "name"})
+		wrapped := NewErrorWrapped("handler", invalid)
+		assert.Equal(t, "字段 name 无效", LocalizeError(wrapped, "zh"))
+		// template fails without details
+		assert.Equal(t, "field invalid", LocalizeError(NewError("test.Invalid", "field invalid"), "zh"))
+
+		// replaced by code
+		assert.Nil(t, SetErrorMessages("en", map[string]string{"test.NotFound": "{{.Message}}!"}))
+		assert.Equal(t, "resource u1 not found!", LocalizeError(notFound, "en"))
+	})
+
+	t.Run("Render", func(t *testing.T) {
+		assert.Nil(t, RegisterErrorCode(ErrorCode{Code: "test.NotFound", HTTPStatus: http.StatusNotFound}))
+		status, body := RenderLocalizedError(NewError("test.NotFound", "resource %s not found", "u2"), "zh")
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.JSONEq(t, `{"error":{"code":"test.NotFound","message":"未找到资源 u2"}}`, string(body))
+	})
+}
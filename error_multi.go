@@ -0,0 +1,132 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"strings"
+	"sync"
+)
+
+// TaskError original error of a task with its name
+type TaskError struct {
+	Name string
+	Err  error
+}
+
+// Error string of Err
+func (e *TaskError) Error() string { return e.Err.Error() }
+
+// Unwrap Err
+func (e *TaskError) Unwrap() error { return e.Err }
+
+// MultiError aggregates errors, e.g. failures of tasks in pipeline
+// each error is preserved and can be traversed by errors.Is & errors.As
+type MultiError interface {
+	ErrorTao
+	// Append err of task named name, nil err is ignored
+	Append(name string, err error)
+	// Errors in order of appending
+	Errors() []error
+	// Unwrap to Errors
+	Unwrap() []error
+}
+
+var _ MultiError = (*multiError)(nil)
+
+// multiError implements MultiError
+type multiError struct {
+	mu sync.RWMutex
+
+	code    string
+	errs    []error
+	details []interface{}
+}
+
+// NewMultiError constructor of MultiError
+func NewMultiError(code string, errs ...error) MultiError {
+	e := &multiError{code: code}
+	for _, err := range errs {
+		e.Wrap(err)
+	}
+	return e
+}
+
+// Code string
+func (e *multiError) Code() string { return e.code }
+
+// Error string of the only error, or all errors joined
+func (e *multiError) Error() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	switch len(e.errs) {
+	case 0:
+		return ""
+	case 1:
+		return e.errs[0].Error()
+	}
+	msg := make([]string, 0, len(e.errs))
+	for _, err := range e.errs {
+		msg = append(msg, err.Error())
+	}
+	return "<" + e.code + ">" + strings.Join(msg, errSplit)
+}
+
+// Wrap err without name
+func (e *multiError) Wrap(err error) {
+	if err == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.errs = append(e.errs, err)
+}
+
+// Append err of task named name
+func (e *multiError) Append(name string, err error) {
+	if err == nil {
+		return
+	}
+	e.Wrap(&TaskError{Name: name, Err: err})
+}
+
+// Cause of error, the first one
+func (e *multiError) Cause() error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if len(e.errs) == 0 {
+		return nil
+	}
+	return e.errs[0]
+}
+
+// Details attached
+func (e *multiError) Details() []interface{} {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return append([]interface{}(nil), e.details...)
+}
+
+// Errors in order of appending
+func (e *multiError) Errors() []error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return append([]error(nil), e.errs...)
+}
+
+// Unwrap to Errors
+func (e *multiError) Unwrap() []error { return e.Errors() }
+
+// Is an ErrorTao with same code
+func (e *multiError) Is(target error) bool { return isCode(e, target) }
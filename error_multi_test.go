@@ -0,0 +1,109 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiError(t *testing.T) {
+	t.Run("Error", func(t *testing.T) {
+		e := NewMultiError(Unknown)
+		assert.Equal(t, "", e.Error())
+		assert.Nil(t, e.Cause())
+
+		e.Append("a", nil)
+		e.Append("a", errors.New("error a"))
+		assert.Equal(t, "error a", e.Error())
+
+		e.Wrap(errors.New("error b"))
+		assert.Equal(t, "<Unknown>error a"+errSplit+"error b", e.Error())
+		assert.Equal(t, "error a", e.Cause().Error())
+		assert.Len(t, e.Errors(), 2)
+		assert.Equal(t, Unknown, e.Code())
+	})
+
+	t.Run("IsAs", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		e := NewMultiError(Unknown)
+		e.Append("a", ctx.Err())
+		e.Append("b", NewError(TaskClosed, "closed"))
+		wrapped := NewErrorWrapped("tao: fail to run", e)
+
+		assert.True(t, errors.Is(wrapped, context.Canceled))
+		assert.True(t, errors.Is(wrapped, NewError(TaskClosed, "another message")))
+		assert.False(t, errors.Is(wrapped, NewError(TaskRunning, "closed")))
+
+		var taskErr *TaskError
+		assert.True(t, errors.As(wrapped, &taskErr))
+		assert.Equal(t, "a", taskErr.Name)
+		assert.Equal(t, context.Canceled, taskErr.Err)
+
+		var multi MultiError
+		assert.True(t, errors.As(wrapped, &multi))
+		assert.Equal(t, e, multi)
+	})
+
+	t.Run("HasCode", func(t *testing.T) {
+		e := NewMultiError(Unknown, errors.New("std"))
+		inner := NewError(ContextCanceled, "canceled")
+		inner.Wrap(NewError(TaskClosed, "closed"))
+		e.Append("a", inner)
+		err := fmt.Errorf("std: %w", e)
+
+		assert.True(t, HasCode(err, Unknown))
+		assert.True(t, HasCode(err, ContextCanceled))
+		assert.False(t, HasCode(err, TaskRunning))
+		assert.False(t, HasCode(nil, Unknown))
+	})
+
+	t.Run("Pipeline", func(t *testing.T) {
+		p := NewPipeline("multi")
+		fail := func(name string, err error, runAfter ...string) *PipeTask {
+			return NewPipeTask(NewTask(name, func(ctx context.Context, param Parameter) (Parameter, error) {
+				return param, err
+			}, SetClose(func() error {
+				return NewError(TaskClosed, "%s closed", name)
+			})), runAfter...)
+		}
+		assert.Nil(t, p.Register(fail("x", context.DeadlineExceeded)))
+		assert.Nil(t, p.Register(fail("y", NewError(ParamInvalid, "invalid"), "x")))
+
+		err := p.Run(context.Background(), nil)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.True(t, HasCode(err, ParamInvalid))
+
+		var multi MultiError
+		assert.True(t, errors.As(err, &multi))
+		names := make([]string, 0)
+		for _, e := range multi.Errors() {
+			names = append(names, e.(*TaskError).Name)
+		}
+		assert.Equal(t, []string{"x", "y"}, names)
+		assert.Equal(t, err.Error(), p.Error())
+
+		err = p.Close()
+		assert.True(t, HasCode(err, TaskClosed))
+		assert.True(t, errors.As(err, &multi))
+		assert.Len(t, multi.Errors(), 2)
+	})
+}
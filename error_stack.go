@@ -0,0 +1,129 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+)
+
+// ErrorConfig of tao
+type ErrorConfig struct {
+	// Stack captured when errors are constructed
+	Stack bool `json:"stack"`
+	// Messages files of locales, e.g. zh-CN: ./conf/errors.zh-CN.yaml
+	Messages map[string]string `json:"messages,omitempty"`
+}
+
+// errorStack enabled or not
+var errorStack atomic.Bool
+
+// SetErrorStack to capture stack when errors are constructed by NewError & NewErrorWrapped
+// it's disabled by default to avoid cost in hot paths
+func SetErrorStack(enable bool) {
+	errorStack.Store(enable)
+}
+
+// ErrorStacker error with stack captured when constructed
+type ErrorStacker interface {
+	error
+	// Frames of stack, nil if not captured
+	Frames() []runtime.Frame
+}
+
+// maxStackDepth of errors
+const maxStackDepth = 32
+
+// stack of program counters
+type stack []uintptr
+
+// callers after skip frames if stack enabled
+func callers(skip int) stack {
+	if !errorStack.Load() {
+		return nil
+	}
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(skip+1, pcs)
+	return pcs[:n]
+}
+
+// frames of stack
+func (s stack) frames() []runtime.Frame {
+	if len(s) == 0 {
+		return nil
+	}
+	frames := make([]runtime.Frame, 0, len(s))
+	iter := runtime.CallersFrames(s)
+	for {
+		frame, more := iter.Next()
+		frames = append(frames, frame)
+		if !more {
+			break
+		}
+	}
+	return frames
+}
+
+// writeStack like panic, function & file:line of each frame
+func writeStack(w io.Writer, frames []runtime.Frame) {
+	for _, f := range frames {
+		_, _ = io.WriteString(w, "\n"+f.Function+"\n\t"+f.File+":"+strconv.Itoa(f.Line))
+	}
+}
+
+// StackOf err, frames of the deepest error in chain which has stack captured
+func StackOf(err error) []runtime.Frame {
+	var frames []runtime.Frame
+	for err != nil {
+		if s, ok := err.(ErrorStacker); ok {
+			if f := s.Frames(); len(f) != 0 {
+				frames = f
+			}
+		}
+		if c, ok := err.(interface{ Cause() error }); ok {
+			// e.g. ErrorTao & MultiError
+			err = c.Cause()
+		} else {
+			err = errors.Unwrap(err)
+		}
+	}
+	return frames
+}
+
+// formatError for fmt.Formatter, %+v prints message, stack & the full cause chain
+func formatError(s fmt.State, verb rune, err error, msg string, frames []runtime.Frame, causes ...error) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			_, _ = io.WriteString(s, msg)
+			writeStack(s, frames)
+			for _, cause := range causes {
+				if cause != nil {
+					_, _ = fmt.Fprintf(s, "\ncaused by: %+v", cause)
+				}
+			}
+			return
+		}
+		fallthrough
+	case 's':
+		_, _ = io.WriteString(s, err.Error())
+	case 'q':
+		_, _ = fmt.Fprintf(s, "%q", err.Error())
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorStack(t *testing.T) {
+	t.Run("Disabled", func(t *testing.T) {
+		e := NewError(Unknown, "no stack")
+		assert.Nil(t, e.(ErrorStacker).Frames())
+		assert.Nil(t, StackOf(e))
+		assert.Equal(t, "<Unknown>no stack", fmt.Sprintf("%+v", e))
+	})
+
+	SetErrorStack(true)
+	defer SetErrorStack(false)
+
+	t.Run("Frames", func(t *testing.T) {
+		e := NewError(ContextCanceled, "canceled")
+		frames := e.(ErrorStacker).Frames()
+		assert.NotEmpty(t, frames)
+		assert.True(t, strings.HasSuffix(frames[0].Function, "TestErrorStack.func2"))
+		assert.True(t, strings.HasSuffix(frames[0].File, "error_stack_test.go"))
+
+		wrapped := NewErrorWrapped("wrapped", nil)
+		frames = wrapped.(ErrorStacker).Frames()
+		assert.NotEmpty(t, frames)
+		assert.True(t, strings.HasSuffix(frames[0].Function, "TestErrorStack.func2"))
+	})
+
+	t.Run("Format", func(t *testing.T) {
+		root := NewErrorWrapped("root", nil)
+		e := NewError(TaskRunning, "outer")
+		e.Wrap(root)
+
+		assert.Equal(t, e.Error(), fmt.Sprintf("%v", e))
+		assert.Equal(t, e.Error(), fmt.Sprintf("%s", e))
+		assert.Equal(t, fmt.Sprintf("%q", e.Error()), fmt.Sprintf("%q", e))
+
+		detail := fmt.Sprintf("%+v", e)
+		t.Log(detail)
+		assert.True(t, strings.HasPrefix(detail, "<TaskRunning>outer\n"))
+		assert.Contains(t, detail, "\ncaused by: root\n")
+		assert.Contains(t, detail, "error_stack_test.go:")
+		assert.Equal(t, 2, strings.Count(detail, "TestErrorStack.func3\n"))
+
+		e.Wrap(errors.New("another"))
+		detail = fmt.Sprintf("%+v", e)
+		assert.Contains(t, detail, "\ncaused by: another")
+		assert.Contains(t, detail, "\ncaused by: root\n")
+	})
+
+	t.Run("ErrorsIsAs", func(t *testing.T) {
+		root := NewErrorWrapped("root", nil)
+		e := NewErrorWrapped("outer", root)
+		assert.True(t, errors.Is(e, root))
+
+		var stacker ErrorStacker
+		assert.True(t, errors.As(e, &stacker))
+		assert.Equal(t, e, stacker)
+
+		// deepest stack
+		assert.Equal(t, root.(ErrorStacker).Frames(), StackOf(e))
+		assert.Equal(t, root.(ErrorStacker).Frames(), StackOf(fmt.Errorf("std: %w", e)))
+		assert.Nil(t, StackOf(errors.New("std")))
+
+		tao := NewError(Unknown, "tao")
+		tao.Wrap(e)
+		assert.Equal(t, root.(ErrorStacker).Frames(), StackOf(tao))
+	})
+}
@@ -15,6 +15,7 @@
 package tao
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
@@ -49,6 +50,8 @@ func TestNewErrorWrapped(t *testing.T) {
 	t.Run("TestErrorWrapped_Is", func(t *testing.T) {
 		assert.Equal(t, true, errors.Is(err2, err1))
 		assert.Equal(t, true, errors.Is(err3, err2))
+		assert.Equal(t, false, errors.Is(NewErrorWrapped("same", nil), errors.New("same")))
+		assert.Equal(t, false, errors.Is(err2, NewErrorWrapped("err1", nil)))
 	})
 
 	t.Run("TestErrorWrapped_As", func(t *testing.T) {
@@ -75,17 +78,25 @@ func TestNewError(t *testing.T) {
 	})
 
 	t.Run("TestError_Cause", func(t *testing.T) {
-		assert.Equal(t, NewErrorWrapped(err5.Error(), err4), err.Cause())
+		assert.Equal(t, err4, err.Cause())
+		assert.Equal(t, []error{err5, err4}, err.(interface{ Unwrap() []error }).Unwrap())
 	})
 
 	t.Run("TestError_Is", func(t *testing.T) {
 		assert.Equal(t, true, errors.Is(err, err4))
-		assert.Equal(t, true, errors.Is(err, NewErrorWrapped(err5.Error(), err4)))
+		assert.Equal(t, true, errors.Is(err, err5))
+		assert.Equal(t, false, errors.Is(err, errors.New(err5.Error())))
+
+		e := NewError(ContextCanceled, "tao: canceled")
+		e.Wrap(context.Canceled)
+		e.Wrap(context.DeadlineExceeded)
+		assert.Equal(t, true, errors.Is(e, context.Canceled))
+		assert.Equal(t, true, errors.Is(e, context.DeadlineExceeded))
 	})
 
 	t.Run("TestError_As", func(t *testing.T) {
 		var wrapped = new(errorWrapped)
 		assert.Equal(t, true, errors.As(err, &wrapped))
-		assert.Equal(t, NewErrorWrapped(err5.Error(), err4), wrapped)
+		assert.Equal(t, err5, wrapped)
 	})
 }
@@ -0,0 +1,242 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GraphFormat of pipeline graph
+type GraphFormat uint8
+
+const (
+	// DOT text of Graphviz
+	DOT GraphFormat = iota
+	// Mermaid flowchart text
+	Mermaid
+)
+
+// PipelineGrapher describe Pipeline whose tasks can be rendered & planned
+type PipelineGrapher interface {
+	Pipeline
+	// Graph of tasks in format, annotated with state, duration & error of each task if needed
+	Graph(format GraphFormat, annotate bool) string
+	// Layers of tasks in running order, tasks in the same layer run in parallel
+	Layers() ([][]string, error)
+}
+
+var _ PipelineGrapher = (*pipeline)(nil)
+
+// graphNode describe one task in graph
+type graphNode struct {
+	name     string
+	runAfter []string
+	// missing node is referred by runAfter but not registered
+	missing bool
+	failed  bool
+	notes   []string
+}
+
+// Graph renders registered tasks & their runAfter edges of pipeline
+// annotate with current state, duration & error of each task if needed
+// it doesn't wait for the pipeline running, duration of running tasks is measured until now
+func (p *pipeline) Graph(format GraphFormat, annotate bool) string {
+	p.regMu.RLock()
+	defer p.regMu.RUnlock()
+
+	nodes := make([]*graphNode, 0, len(p.tasks))
+	for _, task := range p.tasks {
+		node := &graphNode{
+			name:     task.Name(),
+			runAfter: task.runAfter,
+		}
+		if annotate {
+			// Task is locked while running
+			if elapsed, running := task.elapsed(); running {
+				node.notes = append(node.notes, Running.String()+" "+elapsed.String())
+			} else {
+				state := task.State()
+				node.notes = append(node.notes, state.String())
+				if state != Runnable {
+					node.notes[0] += " " + elapsed.String()
+				}
+				if e := task.Error(); e != "" {
+					node.failed = true
+					node.notes = append(node.notes, e)
+				}
+			}
+		}
+		nodes = append(nodes, node)
+	}
+
+	// dependencies not registered
+	missing := make(map[string]struct{})
+	for _, task := range p.tasks {
+		for _, pre := range task.runAfter {
+			if _, ok := p.signals[pre]; ok {
+				continue
+			}
+			if _, ok := missing[pre]; ok {
+				continue
+			}
+			missing[pre] = struct{}{}
+			nodes = append(nodes, &graphNode{name: pre, missing: true})
+		}
+	}
+
+	switch format {
+	case Mermaid:
+		return mermaidGraph(p.name, nodes)
+	default:
+		return dotGraph(p.name, nodes)
+	}
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func dotGraph(name string, nodes []*graphNode) string {
+	var b strings.Builder
+	quote := func(s string) string {
+		return `"` + dotEscaper.Replace(s) + `"`
+	}
+
+	fmt.Fprintf(&b, "digraph %s {\n", quote(name))
+	b.WriteString("\trankdir=LR;\n")
+	for _, node := range nodes {
+		attrs := make([]string, 0, 3)
+		if len(node.notes) != 0 {
+			attrs = append(attrs, "label="+quote(strings.Join(append([]string{node.name}, node.notes...), "\n")))
+		}
+		if node.failed {
+			attrs = append(attrs, "color=red")
+		}
+		if node.missing {
+			attrs = append(attrs, "style=dashed")
+		}
+		if len(attrs) == 0 {
+			fmt.Fprintf(&b, "\t%s;\n", quote(node.name))
+		} else {
+			fmt.Fprintf(&b, "\t%s [%s];\n", quote(node.name), strings.Join(attrs, ", "))
+		}
+	}
+	for _, node := range nodes {
+		for _, pre := range node.runAfter {
+			fmt.Fprintf(&b, "\t%s -> %s;\n", quote(pre), quote(node.name))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+var mermaidEscaper = strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;", "\n", "<br/>")
+
+func mermaidGraph(name string, nodes []*graphNode) string {
+	var (
+		b       strings.Builder
+		ids     = make(map[string]string, len(nodes))
+		failed  bool
+		missing bool
+	)
+
+	// mermaid ids are restricted, so nodes are numbered & labeled by name
+	for i, node := range nodes {
+		ids[node.name] = fmt.Sprintf("t%d", i)
+	}
+
+	fmt.Fprintf(&b, "%%%% pipeline %s\n", name)
+	b.WriteString("flowchart LR\n")
+	for _, node := range nodes {
+		label := mermaidEscaper.Replace(strings.Join(append([]string{node.name}, node.notes...), "\n"))
+		fmt.Fprintf(&b, "\t%s[\"%s\"]", ids[node.name], label)
+		switch {
+		case node.failed:
+			failed = true
+			b.WriteString(":::failed")
+		case node.missing:
+			missing = true
+			b.WriteString(":::missing")
+		}
+		b.WriteString("\n")
+	}
+	for _, node := range nodes {
+		for _, pre := range node.runAfter {
+			fmt.Fprintf(&b, "\t%s --> %s\n", ids[pre], ids[node.name])
+		}
+	}
+	if failed {
+		b.WriteString("\tclassDef failed stroke:#f00\n")
+	}
+	if missing {
+		b.WriteString("\tclassDef missing stroke-dasharray:5 5\n")
+	}
+	return b.String()
+}
+
+// Layers of pipeline computed from runAfter of tasks
+// tasks in the same layer run in parallel, layer by layer
+// dependencies not registered & cycles are reported as error
+func (p *pipeline) Layers() ([][]string, error) {
+	p.regMu.RLock()
+	defer p.regMu.RUnlock()
+
+	pending := make(map[string]int, len(p.tasks))
+	for _, task := range p.tasks {
+		for _, pre := range task.runAfter {
+			if _, ok := p.signals[pre]; !ok {
+				return nil, NewError(DependencyNotFound, "pipeline: task %q run after %q which is not registered in %q", task.Name(), pre, p.name)
+			}
+		}
+		pending[task.Name()] = len(task.runAfter)
+	}
+
+	layers := make([][]string, 0)
+	done := make(map[string]struct{}, len(p.tasks))
+	for len(done) < len(p.tasks) {
+		layer := make([]string, 0)
+		for _, task := range p.tasks {
+			name := task.Name()
+			if _, ok := done[name]; ok || pending[name] != 0 {
+				continue
+			}
+			layer = append(layer, name)
+		}
+
+		if len(layer) == 0 {
+			cycle := make([]string, 0)
+			for _, task := range p.tasks {
+				if _, ok := done[task.Name()]; !ok {
+					cycle = append(cycle, task.Name())
+				}
+			}
+			return nil, NewError(DependencyCycle, "pipeline: cycle found in %q among tasks %q", p.name, cycle)
+		}
+
+		for _, name := range layer {
+			done[name] = struct{}{}
+		}
+		for _, task := range p.tasks {
+			for _, pre := range task.runAfter {
+				for _, name := range layer {
+					if pre == name {
+						pending[task.Name()]--
+					}
+				}
+			}
+		}
+		layers = append(layers, layer)
+	}
+	return layers, nil
+}
@@ -0,0 +1,144 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipelineGraph(t *testing.T) {
+	p := NewPipeline("graph").(PipelineGrapher)
+	assert.Nil(t, p.Register(NewPipeTask(NewTask("a", func(ctx context.Context, param Parameter) (Parameter, error) {
+		return param, nil
+	}))))
+	assert.Nil(t, p.Register(NewPipeTask(NewTask("b.1", func(ctx context.Context, param Parameter) (Parameter, error) {
+		return param, errors.New(`"b" failed`)
+	}), "a", "x")))
+
+	t.Run("DOT", func(t *testing.T) {
+		assert.Equal(t, `digraph "graph" {
+	rankdir=LR;
+	"a";
+	"b.1";
+	"x" [style=dashed];
+	"a" -> "b.1";
+	"x" -> "b.1";
+}
+`, p.Graph(DOT, false))
+	})
+
+	t.Run("Mermaid", func(t *testing.T) {
+		assert.Equal(t, `%% pipeline graph
+flowchart LR
+	t0["a"]
+	t1["b.1"]
+	t2["x"]:::missing
+	t0 --> t1
+	t2 --> t1
+	classDef missing stroke-dasharray:5 5
+`, p.Graph(Mermaid, false))
+	})
+
+	t.Run("Annotate", func(t *testing.T) {
+		assert.Contains(t, p.Graph(DOT, true), `"a" [label="a\nrunnable"];`)
+
+		assert.NotNil(t, p.Run(context.Background(), nil))
+
+		dot := p.Graph(DOT, true)
+		t.Log(dot)
+		assert.Contains(t, dot, `"a" [label="a\nover `)
+		assert.Contains(t, dot, `\n\"b\" failed", color=red];`)
+
+		mermaid := p.Graph(Mermaid, true)
+		t.Log(mermaid)
+		assert.Contains(t, mermaid, `<br/>#quot;b#quot; failed"]:::failed`)
+		assert.Contains(t, mermaid, "classDef failed stroke:#f00")
+	})
+}
+
+func TestPipelineGraphRunning(t *testing.T) {
+	started, block := make(chan struct{}), make(chan struct{})
+	p := NewPipeline("running").(PipelineGrapher)
+	assert.Nil(t, p.Register(NewPipeTask(NewTask("a", func(ctx context.Context, param Parameter) (Parameter, error) {
+		close(started)
+		<-block
+		return param, nil
+	}))))
+
+	done := make(chan error)
+	go func() {
+		done <- p.Run(context.Background(), nil)
+	}()
+	<-started
+	time.Sleep(10 * time.Millisecond)
+
+	// not blocked by Run
+	dot := p.Graph(DOT, true)
+	t.Log(dot)
+	assert.Contains(t, dot, `"a" [label="a\nrunning `)
+	assert.NotContains(t, dot, `running 0s`)
+	layers, err := p.Layers()
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"a"}}, layers)
+
+	close(block)
+	assert.Nil(t, <-done)
+	assert.Nil(t, p.Close())
+}
+
+func TestPipelineLayers(t *testing.T) {
+	newTask := func(name string) Task {
+		return NewTask(name, func(ctx context.Context, param Parameter) (Parameter, error) {
+			return param, nil
+		})
+	}
+
+	t.Run("Layers", func(t *testing.T) {
+		p := NewPipeline("layers").(PipelineGrapher)
+		assert.Nil(t, p.Register(NewPipeTask(newTask("d"), "b", "c")))
+		assert.Nil(t, p.Register(NewPipeTask(newTask("a"))))
+		assert.Nil(t, p.Register(NewPipeTask(newTask("b"), "a")))
+		assert.Nil(t, p.Register(NewPipeTask(newTask("c"), "a")))
+		assert.Nil(t, p.Register(NewPipeTask(newTask("e"))))
+
+		layers, err := p.Layers()
+		assert.Nil(t, err)
+		assert.Equal(t, [][]string{{"a", "e"}, {"b", "c"}, {"d"}}, layers)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		p := NewPipeline("not found").(PipelineGrapher)
+		assert.Nil(t, p.Register(NewPipeTask(newTask("a"), "x")))
+
+		_, err := p.Layers()
+		assert.Equal(t, DependencyNotFound, err.(ErrorTao).Code())
+	})
+
+	t.Run("Cycle", func(t *testing.T) {
+		p := NewPipeline("cycle").(PipelineGrapher)
+		assert.Nil(t, p.Register(NewPipeTask(newTask("a"))))
+		assert.Nil(t, p.Register(NewPipeTask(newTask("b"), "a", "c")))
+		assert.Nil(t, p.Register(NewPipeTask(newTask("c"), "b")))
+
+		_, err := p.Layers()
+		assert.Equal(t, DependencyCycle, err.(ErrorTao).Code())
+		t.Log(err)
+	})
+}
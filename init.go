@@ -20,7 +20,6 @@ import (
 	"gopkg.in/yaml.v3"
 	"io"
 	"io/ioutil"
-	"log"
 	"os"
 	"path"
 	"strings"
@@ -115,22 +114,92 @@ func SetAllConfigBytes(data []byte, configType ConfigType) (err error) {
 // t global config of tao
 var t = new(taoConfig)
 
+var (
+	// logCapture of taotest, which replaces all writers of log
+	logCapture io.Writer
+	// logClosers of writers opened by taoInit
+	logClosers []io.Closer
+)
+
 // taoInit can only be called once before tao.Run
 func taoInit() (err error) {
+	// stack & messages of errors
+	if t.Error != nil {
+		SetErrorStack(t.Error.Stack)
+		for locale, file := range t.Error.Messages {
+			err = LoadErrorMessages(locale, file)
+			if err != nil {
+				return NewErrorWrapped("init: fail to load error messages", err)
+			}
+		}
+	}
+
+	// levels of loggers
+	for name, level := range t.Log.Levels {
+		err = SetLogLevel(name, level)
+		if err != nil {
+			return NewErrorWrapped("init: fail to set log level", err)
+		}
+	}
+
 	// SetLogger
 	if !t.Log.Disable {
 		writers := make([]io.Writer, 0)
+		outputs := make([]*logOutput, 0)
+
+		logType := t.Log.Type
+		if logCapture != nil {
+			// captured by taotest
+			logType = 0
+			writers = append(writers, logCapture)
+		}
 
-		if t.Log.Type&Console != 0 {
+		if logType&Console != 0 {
 			writers = append(writers, os.Stdout)
 		}
 
-		if t.Log.Type&File != 0 {
-			file, err := os.OpenFile(t.Log.Path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+		if logType&File != 0 {
+			file, err := newRotateWriter(t.Log.Path, t.Log.Rotate)
 			if err != nil {
 				return NewErrorWrapped("init: fail to open log file", err)
 			}
 			writers = append(writers, file)
+			logClosers = append(logClosers, file)
+		}
+
+		// async of console & file log
+		for i, w := range writers {
+			if t.Log.Async != nil {
+				a := newAsyncWriter(w, t.Log.Async)
+				writers[i], w = a, a
+				logClosers = append(logClosers, a)
+			}
+			o := &logOutput{w: w, format: t.Log.Format}
+			// pretty console log
+			if i == 0 && logType&Console != 0 && t.Log.Pretty != nil {
+				o.pretty = newPrettyEncoder(t.Log.Pretty)
+			}
+			outputs = append(outputs, o)
+		}
+
+		if logType&Syslog != 0 {
+			w, err := newSyslogWriter(t.Log.Syslog)
+			if err != nil {
+				return NewErrorWrapped("init: fail to connect syslog", err)
+			}
+			writers = append(writers, w)
+			outputs = append(outputs, &logOutput{w: w, format: t.Log.Format})
+			logClosers = append(logClosers, w)
+		}
+
+		if logType&Journald != 0 {
+			w, err := newJournaldWriter(t.Log.Journald)
+			if err != nil {
+				return NewErrorWrapped("init: fail to connect journald", err)
+			}
+			writers = append(writers, w)
+			outputs = append(outputs, &logOutput{w: w, format: t.Log.Format})
+			logClosers = append(logClosers, w)
 		}
 
 		writer := io.MultiWriter(writers...)
@@ -139,7 +208,11 @@ func taoInit() (err error) {
 			return NewErrorWrapped("init: fail to set writer for 'tao'", err)
 		}
 
-		err = SetLogger(ConfigKey, &logger{Logger: log.New(writer, "", int(t.Log.Flag)), calldepth: t.Log.CallDepth})
+		l := newLogger(ConfigKey, t.Log.Flag, t.Log.CallDepth, outputs...)
+		if t.Log.Sampling != nil {
+			l.sample(*t.Log.Sampling)
+		}
+		err = SetLogger(ConfigKey, l)
 		if err != nil {
 			return NewErrorWrapped("init: fail to set logger for 'tao'", err)
 		}
@@ -166,7 +239,7 @@ func universeInit() error {
 		return NewError(TaskRunTwice, "universe: init twice")
 	}
 	// universe run
-	timeout, cancel := context.WithTimeout(context.Background(), time.Minute)
+	timeout, cancel := WithTimeout(GetClock(), context.Background(), time.Minute)
 	defer cancel()
 	return tao.universe.Run(timeout, nil)
 }
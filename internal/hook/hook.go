@@ -0,0 +1,32 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hook shares internals of tao with taotest
+package hook
+
+import (
+	"io"
+	"sync"
+)
+
+var (
+	// Mu serializes universes of tests, which share global state of tao
+	Mu sync.Mutex
+
+	// Reset global state of tao, the universe & writers of logs are closed
+	Reset func()
+
+	// CaptureLogs of tao into w instead of console, file, syslog & journald, nil to stop capturing
+	CaptureLogs func(w io.Writer)
+)
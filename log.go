@@ -16,22 +16,36 @@ package tao
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"runtime"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 // Log config in tao
 type Log struct {
-	Level     LogLevel `json:"level"`
-	Type      LogType  `json:"type"`
-	Flag      LogFlag  `json:"flag"`
-	CallDepth int      `json:"call_depth"`
-	Path      string   `json:"path,omitempty"`
-	Disable   bool     `json:"disable"`
+	Level     LogLevel  `json:"level"`
+	Type      LogType   `json:"type"`
+	Format    LogFormat `json:"format"`
+	Flag      LogFlag   `json:"flag"`
+	CallDepth int       `json:"call_depth"`
+	Path      string    `json:"path,omitempty"`
+	Rotate    *Rotate   `json:"rotate,omitempty"`
+	Async     *Async    `json:"async,omitempty"`
+	Sampling  *Sampling `json:"sampling,omitempty"`
+	Pretty    *Pretty   `json:"pretty,omitempty"`
+	// Syslog & Journald config of their log types
+	Syslog   *SyslogConfig   `json:"syslog,omitempty"`
+	Journald *JournaldConfig `json:"journald,omitempty"`
+	Disable  bool            `json:"disable"`
+	// Levels of loggers by name, e.g. {redis: debug, http: warn}
+	Levels map[string]LogLevel `json:"levels,omitempty"`
 }
 
 // LogLevel log's level
@@ -84,7 +98,7 @@ func (l *LogLevel) UnmarshalText(text []byte) error {
 		*l = DEBUG
 	case "info":
 		*l = INFO
-	case "warning":
+	case "warning", "warn":
 		*l = WARNING
 	case "error":
 		*l = ERROR
@@ -106,20 +120,37 @@ const (
 	Console LogType = 1 // 0b1
 	// File log
 	File LogType = 2 // 0b10
+	// Syslog log in RFC 5424
+	Syslog LogType = 4 // 0b100
+	// Journald log in its native protocol
+	Journald LogType = 8 // 0b1000
 )
 
+// logTypes in order of String
+var logTypes = []struct {
+	t    LogType
+	name string
+}{
+	{Console, "console"},
+	{File, "file"},
+	{Syslog, "syslog"},
+	{Journald, "journald"},
+}
+
 // String for LogType Config
 func (l LogType) String() string {
-	switch l {
-	case Console:
-		return "console"
-	case File:
-		return "file"
-	case Console | File:
-		return "console|file"
-	default:
+	names := make([]string, 0, len(logTypes))
+	rest := l
+	for _, lt := range logTypes {
+		if l&lt.t != 0 {
+			names = append(names, lt.name)
+			rest &^= lt.t
+		}
+	}
+	if len(names) == 0 || rest != 0 {
 		return fmt.Sprintf("tao.LOGTYPE(%d)", l)
 	}
+	return strings.Join(names, "|")
 }
 
 // MarshalText instead of number
@@ -127,18 +158,23 @@ func (l LogType) MarshalText() ([]byte, error) {
 	return []byte(l.String()), nil
 }
 
-// UnmarshalText to number
+// UnmarshalText to number, e.g. console|file
 func (l *LogType) UnmarshalText(text []byte) error {
-	switch lower := string(bytes.ToLower(text)); lower {
-	case "console":
-		*l = Console
-	case "file":
-		*l = File
-	case "console|file", "file|console":
-		*l = File | Console
-	default:
-		return fmt.Errorf("log: unrecognized LogType: %q", lower)
+	lower := string(bytes.ToLower(text))
+	var typ LogType
+	for _, name := range strings.Split(lower, "|") {
+		found := false
+		for _, lt := range logTypes {
+			if strings.TrimSpace(name) == lt.name {
+				typ |= lt.t
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("log: unrecognized LogType: %q", lower)
+		}
 	}
+	*l = typ
 	return nil
 }
 
@@ -200,13 +236,186 @@ type Logger interface {
 	Fatalf(format string, v ...interface{})
 }
 
-var _ Logger = (*logger)(nil)
+// StructuredLogger Logger with key-value fields
+// keysAndValues are alternating keys & values, e.g. "unit", "redis", "cost", time.Second
+type StructuredLogger interface {
+	Logger
+
+	// With fields, the returned logger logs them in every line
+	With(keysAndValues ...interface{}) StructuredLogger
+	// Named child logger, whose name is joined to its parent's by dot
+	Named(name string) StructuredLogger
+
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+	Panicw(msg string, keysAndValues ...interface{})
+	Fatalw(msg string, keysAndValues ...interface{})
+
+	// XxxContext logs fields in ctx set by WithLogFields as well
+	DebugContext(ctx context.Context, msg string, keysAndValues ...interface{})
+	InfoContext(ctx context.Context, msg string, keysAndValues ...interface{})
+	WarnContext(ctx context.Context, msg string, keysAndValues ...interface{})
+	ErrorContext(ctx context.Context, msg string, keysAndValues ...interface{})
+	PanicContext(ctx context.Context, msg string, keysAndValues ...interface{})
+	FatalContext(ctx context.Context, msg string, keysAndValues ...interface{})
+}
 
-// logger implements Logger using standard lib
-type logger struct {
-	*log.Logger
+// logFieldsKey of context
+type logFieldsKey struct{}
+
+// WithLogFields to context, which are logged by XxxContext of StructuredLogger
+func WithLogFields(ctx context.Context, keysAndValues ...interface{}) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, logFieldsKey{}, joinFields(LogFields(ctx), keysAndValues))
+}
+
+// LogFields in context set by WithLogFields
+func LogFields(ctx context.Context) []interface{} {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(logFieldsKey{}).([]interface{})
+	return fields
+}
+
+// badKey of field whose key is not string
+const badKey = "!BADKEY"
+
+// joinFields to a new slice
+func joinFields(fields ...[]interface{}) []interface{} {
+	n := 0
+	for _, f := range fields {
+		n += len(f)
+	}
+	if n == 0 {
+		return nil
+	}
+	joined := make([]interface{}, 0, n)
+	for _, f := range fields {
+		joined = append(joined, f...)
+	}
+	return joined
+}
+
+// rangeFields calls f for each key & value
+// like log/slog, a value without string key is keyed by badKey
+func rangeFields(keysAndValues []interface{}, f func(key string, val interface{})) {
+	for i := 0; i < len(keysAndValues); i++ {
+		key, ok := keysAndValues[i].(string)
+		if !ok || i+1 == len(keysAndValues) {
+			f(badKey, keysAndValues[i])
+			continue
+		}
+		f(key, keysAndValues[i+1])
+		i++
+	}
+}
 
+// formatFields as key=value, value is quoted if necessary
+func formatFields(keysAndValues []interface{}) string {
+	var b strings.Builder
+	rangeFields(keysAndValues, func(key string, val interface{}) {
+		if b.Len() != 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(quoteValue(val))
+	})
+	return b.String()
+}
+
+// fieldString of field value
+func fieldString(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// quoteValue of field
+func quoteValue(val interface{}) string {
+	s := fieldString(val)
+	if s == "" || strings.ContainsAny(s, " =\"\t\r\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// appendFields to message of plain Logger
+func appendFields(msg string, keysAndValues []interface{}) string {
+	if len(keysAndValues) == 0 {
+		return msg
+	}
+	return strings.TrimSuffix(msg, "\n") + " " + formatFields(keysAndValues)
+}
+
+var _ StructuredLogger = (*logger)(nil)
+
+// logger implements StructuredLogger
+type logger struct {
+	key     string
+	outputs []*logOutput
+	flag    LogFlag
+	// calldepth of caller called logger directly
 	calldepth int
+	fields    []interface{}
+	sampler   *sampler
+
+	// skip of frames by entry points wrapping logger, e.g. package functions
+	skip int
+	// wrapper with skip of one frame, created lazily
+	wrapper atomic.Pointer[logger]
+}
+
+// wrapped logger for entry points wrapping logger with one frame, e.g. package functions & broadcastLogger
+func (l *logger) wrapped() *logger {
+	if w := l.wrapper.Load(); w != nil {
+		return w
+	}
+	w := &logger{
+		key:       l.key,
+		outputs:   l.outputs,
+		flag:      l.flag,
+		calldepth: l.calldepth,
+		fields:    l.fields,
+		sampler:   l.sampler,
+		skip:      l.skip + 1,
+	}
+	l.wrapper.CompareAndSwap(nil, w)
+	return l.wrapper.Load()
+}
+
+// NewLogger of configKey, which writes to w in format of tao config
+func NewLogger(configKey string, w io.Writer) StructuredLogger {
+	c := logConfig()
+	return newLogger(configKey, c.Flag, c.CallDepth, &logOutput{w: w, format: c.Format})
+}
+
+// newLogger constructor of logger
+func newLogger(configKey string, flag LogFlag, calldepth int, outputs ...*logOutput) *logger {
+	return &logger{
+		key:       configKey,
+		outputs:   outputs,
+		flag:      flag,
+		calldepth: calldepth,
+	}
+}
+
+// logConfig of tao, default one before tao init
+func logConfig() *Log {
+	if t.Log == nil {
+		return defaultTao.Log
+	}
+	return t.Log
 }
 
 // levelPrefix to define log prefix of log level
@@ -219,106 +428,379 @@ var levelPrefix = map[LogLevel]string{
 	FATAL:   "[F] ",
 }
 
+// logLevels of loggers by name, map[string]LogLevel copied on write
+var (
+	logLevels   atomic.Value
+	logLevelsMu sync.Mutex
+)
+
+// SetLogLevel of logger named name at runtime, which applies to its children as well
+// name of ConfigKey is the root level, which overwrites tao.log.level
+func SetLogLevel(name string, level LogLevel) error {
+	if level < DEBUG || level > FATAL {
+		return NewError(ParamInvalid, "log: invalid level %d of %s", level, name)
+	}
+	logLevelsMu.Lock()
+	defer logLevelsMu.Unlock()
+
+	old, _ := logLevels.Load().(map[string]LogLevel)
+	levels := make(map[string]LogLevel, len(old)+1)
+	for k, v := range old {
+		levels[k] = v
+	}
+	levels[levelName(name)] = level
+	logLevels.Store(levels)
+	return nil
+}
+
+// ResetLogLevel of logger named name, which inherits its parent's level then
+func ResetLogLevel(name string) {
+	logLevelsMu.Lock()
+	defer logLevelsMu.Unlock()
+
+	old, _ := logLevels.Load().(map[string]LogLevel)
+	if _, ok := old[levelName(name)]; !ok {
+		return
+	}
+	levels := make(map[string]LogLevel, len(old))
+	for k, v := range old {
+		if k != levelName(name) {
+			levels[k] = v
+		}
+	}
+	logLevels.Store(levels)
+}
+
+// GetLogLevel of logger named name
+// e.g. level of "redis.pool" is looked up by "redis.pool", "redis" and then the root level
+func GetLogLevel(name string) LogLevel {
+	levels, _ := logLevels.Load().(map[string]LogLevel)
+	for name = levelName(name); name != ConfigKey; {
+		if level, ok := levels[name]; ok {
+			return level
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	if level, ok := levels[ConfigKey]; ok {
+		return level
+	}
+	return logConfig().Level
+}
+
+// levelName of logger, children of tao's logger are named without prefix "tao."
+func levelName(name string) string {
+	if name == "" {
+		return ConfigKey
+	}
+	return strings.TrimPrefix(name, ConfigKey+".")
+}
+
+// enabled level of logger
+func (l *logger) enabled(level LogLevel) bool {
+	return GetLogLevel(l.key) <= level
+}
+
+// output message & fields in level if sampled, called by methods of logger directly
+// template of message is used by sampler, it's message itself if empty
+// text of the line without header is returned
+func (l *logger) output(level LogLevel, template, msg string, keysAndValues []interface{}) string {
+	if l.sampler != nil {
+		if template == "" {
+			template = msg
+		}
+		if !l.sampler.allow(l.key, level, template) {
+			return levelPrefix[level] + appendFields(msg, joinFields(l.fields, keysAndValues))
+		}
+	}
+	return l.write(level, msg, keysAndValues)
+}
+
+// write message & fields in level to outputs
+func (l *logger) write(level LogLevel, msg string, keysAndValues []interface{}) string {
+	e := &logEntry{
+		time:    GetClock().Now(),
+		level:   level,
+		key:     l.key,
+		message: msg,
+		fields:  joinFields(l.fields, keysAndValues),
+	}
+
+	if l.needCaller() {
+		var ok bool
+		// calldepth counts from write, which is called by output & method of logger
+		_, e.file, e.line, ok = runtime.Caller(l.calldepth + l.skip)
+		if !ok {
+			e.file, e.line = "???", 0
+		}
+	}
+	l.emit(e)
+	return levelPrefix[level] + appendFields(msg, e.fields)
+}
+
+// needCaller of entry, which is not needed by text without file flags
+func (l *logger) needCaller() bool {
+	needCaller := l.flag&(log.Lshortfile|log.Llongfile) != 0
+	for _, o := range l.outputs {
+		needCaller = needCaller || o.format != TextFormat
+	}
+	return needCaller
+}
+
+// emit entry to outputs, encoded once per format
+func (l *logger) emit(e *logEntry) {
+	encoded := make(map[LogFormat][]byte, 1)
+	for _, o := range l.outputs {
+		if o.pretty != nil {
+			_ = o.write(e, o.pretty.encode(e, l.flag))
+			continue
+		}
+		line, ok := encoded[o.format]
+		if !ok {
+			line = encode(e, o.format, l.flag)
+			encoded[o.format] = line
+		}
+		_ = o.write(e, line)
+	}
+}
+
+// panicLog after logs flushed
+func panicLog(line string) {
+	_ = FlushLogs()
+	panic(line)
+}
+
+// fatalLog exits after logs flushed
+func fatalLog() {
+	_ = FlushLogs()
+	os.Exit(1)
+}
+
+// With fields
+func (l *logger) With(keysAndValues ...interface{}) StructuredLogger {
+	return &logger{
+		key:       l.key,
+		outputs:   l.outputs,
+		flag:      l.flag,
+		calldepth: l.calldepth,
+		fields:    joinFields(l.fields, keysAndValues),
+		sampler:   l.sampler,
+	}
+}
+
+// Named child logger
+func (l *logger) Named(name string) StructuredLogger {
+	key := name
+	if l.key != "" {
+		key = l.key + "." + name
+	}
+	return &logger{
+		key:       key,
+		outputs:   l.outputs,
+		flag:      l.flag,
+		calldepth: l.calldepth,
+		fields:    l.fields,
+		sampler:   l.sampler,
+	}
+}
+
 // Debug logs info in debug level
 func (l *logger) Debug(v ...interface{}) {
-	if t.Log.Level > DEBUG {
+	if !l.enabled(DEBUG) {
 		return
 	}
-	_ = l.Output(l.calldepth, levelPrefix[DEBUG]+fmt.Sprintln(v...))
+	l.output(DEBUG, "", fmt.Sprintln(v...), nil)
 }
 
 // Debugf logs info in debug level
 func (l *logger) Debugf(format string, v ...interface{}) {
-	if t.Log.Level > DEBUG {
+	if !l.enabled(DEBUG) {
 		return
 	}
-	_ = l.Output(l.calldepth, levelPrefix[DEBUG]+fmt.Sprintf(format, v...))
+	l.output(DEBUG, format, fmt.Sprintf(format, v...), nil)
+}
+
+// Debugw logs message & fields in debug level
+func (l *logger) Debugw(msg string, keysAndValues ...interface{}) {
+	if !l.enabled(DEBUG) {
+		return
+	}
+	l.output(DEBUG, msg, msg, keysAndValues)
+}
+
+// DebugContext logs message & fields with fields of ctx in debug level
+func (l *logger) DebugContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if !l.enabled(DEBUG) {
+		return
+	}
+	l.output(DEBUG, msg, msg, joinFields(LogFields(ctx), keysAndValues))
 }
 
 // Info logs info in info level
 func (l *logger) Info(v ...interface{}) {
-	if t.Log.Level > INFO {
+	if !l.enabled(INFO) {
 		return
 	}
-	_ = l.Output(l.calldepth, levelPrefix[INFO]+fmt.Sprintln(v...))
+	l.output(INFO, "", fmt.Sprintln(v...), nil)
 }
 
 // Infof logs info in info level
 func (l *logger) Infof(format string, v ...interface{}) {
-	if t.Log.Level > INFO {
+	if !l.enabled(INFO) {
 		return
 	}
-	_ = l.Output(l.calldepth, levelPrefix[INFO]+fmt.Sprintf(format, v...))
+	l.output(INFO, format, fmt.Sprintf(format, v...), nil)
+}
+
+// Infow logs message & fields in info level
+func (l *logger) Infow(msg string, keysAndValues ...interface{}) {
+	if !l.enabled(INFO) {
+		return
+	}
+	l.output(INFO, msg, msg, keysAndValues)
+}
+
+// InfoContext logs message & fields with fields of ctx in info level
+func (l *logger) InfoContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if !l.enabled(INFO) {
+		return
+	}
+	l.output(INFO, msg, msg, joinFields(LogFields(ctx), keysAndValues))
 }
 
 // Warn logs info in warn level
 func (l *logger) Warn(v ...interface{}) {
-	if t.Log.Level > WARNING {
+	if !l.enabled(WARNING) {
 		return
 	}
-	_ = l.Output(l.calldepth, levelPrefix[WARNING]+fmt.Sprintln(v...))
+	l.output(WARNING, "", fmt.Sprintln(v...), nil)
 }
 
 // Warnf logs info in warn level
 func (l *logger) Warnf(format string, v ...interface{}) {
-	if t.Log.Level > WARNING {
+	if !l.enabled(WARNING) {
+		return
+	}
+	l.output(WARNING, format, fmt.Sprintf(format, v...), nil)
+}
+
+// Warnw logs message & fields in warn level
+func (l *logger) Warnw(msg string, keysAndValues ...interface{}) {
+	if !l.enabled(WARNING) {
+		return
+	}
+	l.output(WARNING, msg, msg, keysAndValues)
+}
+
+// WarnContext logs message & fields with fields of ctx in warn level
+func (l *logger) WarnContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if !l.enabled(WARNING) {
 		return
 	}
-	_ = l.Output(l.calldepth, levelPrefix[WARNING]+fmt.Sprintf(format, v...))
+	l.output(WARNING, msg, msg, joinFields(LogFields(ctx), keysAndValues))
 }
 
 // Error logs info in error level
 func (l *logger) Error(v ...interface{}) {
-	if t.Log.Level > ERROR {
+	if !l.enabled(ERROR) {
 		return
 	}
-	_ = l.Output(l.calldepth, levelPrefix[ERROR]+fmt.Sprintln(v...))
+	l.output(ERROR, "", fmt.Sprintln(v...), nil)
 }
 
 // Errorf logs info in error level
 func (l *logger) Errorf(format string, v ...interface{}) {
-	if t.Log.Level > ERROR {
+	if !l.enabled(ERROR) {
 		return
 	}
-	_ = l.Output(l.calldepth, levelPrefix[ERROR]+fmt.Sprintf(format, v...))
+	l.output(ERROR, format, fmt.Sprintf(format, v...), nil)
+}
+
+// Errorw logs message & fields in error level
+func (l *logger) Errorw(msg string, keysAndValues ...interface{}) {
+	if !l.enabled(ERROR) {
+		return
+	}
+	l.output(ERROR, msg, msg, keysAndValues)
+}
+
+// ErrorContext logs message & fields with fields of ctx in error level
+func (l *logger) ErrorContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if !l.enabled(ERROR) {
+		return
+	}
+	l.output(ERROR, msg, msg, joinFields(LogFields(ctx), keysAndValues))
 }
 
 // Panic logs info in panic level
 func (l *logger) Panic(v ...interface{}) {
-	if t.Log.Level > PANIC {
+	if !l.enabled(PANIC) {
 		return
 	}
-	s := levelPrefix[PANIC] + fmt.Sprintln(v...)
-	_ = l.Output(l.calldepth, s)
-	panic(s)
+	panicLog(l.output(PANIC, "", fmt.Sprintln(v...), nil))
 }
 
 // Panicf logs info in panic level
 func (l *logger) Panicf(format string, v ...interface{}) {
-	if t.Log.Level > PANIC {
+	if !l.enabled(PANIC) {
+		return
+	}
+	panicLog(l.output(PANIC, format, fmt.Sprintf(format, v...), nil))
+}
+
+// Panicw logs message & fields in panic level
+func (l *logger) Panicw(msg string, keysAndValues ...interface{}) {
+	if !l.enabled(PANIC) {
 		return
 	}
-	s := levelPrefix[PANIC] + fmt.Sprintf(format, v...)
-	_ = l.Output(l.calldepth, s)
-	panic(s)
+	panicLog(l.output(PANIC, msg, msg, keysAndValues))
+}
+
+// PanicContext logs message & fields with fields of ctx in panic level
+func (l *logger) PanicContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if !l.enabled(PANIC) {
+		return
+	}
+	panicLog(l.output(PANIC, msg, msg, joinFields(LogFields(ctx), keysAndValues)))
 }
 
 // Fatal logs info in fatal level
 func (l *logger) Fatal(v ...interface{}) {
-	if t.Log.Level > FATAL {
+	if !l.enabled(FATAL) {
 		return
 	}
-	_ = l.Output(l.calldepth, levelPrefix[FATAL]+fmt.Sprintln(v...))
-	os.Exit(1)
+	l.output(FATAL, "", fmt.Sprintln(v...), nil)
+	fatalLog()
 }
 
 // Fatalf logs info in fatal level
 func (l *logger) Fatalf(format string, v ...interface{}) {
-	if t.Log.Level > FATAL {
+	if !l.enabled(FATAL) {
 		return
 	}
-	_ = l.Output(l.calldepth, levelPrefix[FATAL]+fmt.Sprintf(format, v...))
-	os.Exit(1)
+	l.output(FATAL, format, fmt.Sprintf(format, v...), nil)
+	fatalLog()
+}
+
+// Fatalw logs message & fields in fatal level
+func (l *logger) Fatalw(msg string, keysAndValues ...interface{}) {
+	if !l.enabled(FATAL) {
+		return
+	}
+	l.output(FATAL, msg, msg, keysAndValues)
+	fatalLog()
+}
+
+// FatalContext logs message & fields with fields of ctx in fatal level
+func (l *logger) FatalContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if !l.enabled(FATAL) {
+		return
+	}
+	l.output(FATAL, msg, msg, joinFields(LogFields(ctx), keysAndValues))
+	fatalLog()
 }
 
 // Close this logger
@@ -327,19 +809,36 @@ func (l *logger) Close() error {
 }
 
 // taoLogger for tao
+// loggers & writers are copied on write, so that they are read without lock
 type taoLogger struct {
 	mu sync.Mutex
 
-	loggers map[string]Logger
-	writers map[string]io.Writer
+	// loggers map[string]Logger
+	loggers atomic.Value
+	// writers map[string]io.Writer
+	writers atomic.Value
+	// defaultKey string of logger used by package functions
+	defaultKey atomic.Value
 }
 
 // globalLogger which default to provide based log print
 var globalLogger = new(taoLogger)
 
+// loggerMap snapshot of loggers
+func (g *taoLogger) loggerMap() map[string]Logger {
+	loggers, _ := g.loggers.Load().(map[string]Logger)
+	return loggers
+}
+
+// writerMap snapshot of writers
+func (g *taoLogger) writerMap() map[string]io.Writer {
+	writers, _ := g.writers.Load().(map[string]io.Writer)
+	return writers
+}
+
 // GetWriter in tao
 func GetWriter(configKey string) io.Writer {
-	return globalLogger.writers[configKey]
+	return globalLogger.writerMap()[configKey]
 }
 
 // SetWriter to tao
@@ -347,15 +846,17 @@ func SetWriter(configKey string, w io.Writer) error {
 	globalLogger.mu.Lock()
 	defer globalLogger.mu.Unlock()
 
-	if globalLogger.writers == nil {
-		globalLogger.writers = make(map[string]io.Writer)
-	}
-
-	if _, ok := globalLogger.writers[configKey]; ok {
+	old := globalLogger.writerMap()
+	if _, ok := old[configKey]; ok {
 		return NewError(DuplicateCall, "log: %s's writer has been set before", configKey)
 	}
 
-	globalLogger.writers[configKey] = w
+	writers := make(map[string]io.Writer, len(old)+1)
+	for k, v := range old {
+		writers[k] = v
+	}
+	writers[configKey] = w
+	globalLogger.writers.Store(writers)
 	return nil
 }
 
@@ -364,11 +865,19 @@ func DeleteWriter(configKey string) error {
 	globalLogger.mu.Lock()
 	defer globalLogger.mu.Unlock()
 
-	writer, ok := globalLogger.writers[configKey]
+	old := globalLogger.writerMap()
+	writer, ok := old[configKey]
 	if !ok {
 		return NewError(ParamInvalid, "log: %s's writer not set", configKey)
 	}
-	delete(globalLogger.writers, configKey)
+
+	writers := make(map[string]io.Writer, len(old))
+	for k, v := range old {
+		if k != configKey {
+			writers[k] = v
+		}
+	}
+	globalLogger.writers.Store(writers)
 
 	// writer close
 	if l, ok := writer.(io.Closer); ok {
@@ -379,7 +888,7 @@ func DeleteWriter(configKey string) error {
 
 // GetLogger in tao
 func GetLogger(configKey string) Logger {
-	return globalLogger.loggers[configKey]
+	return globalLogger.loggerMap()[configKey]
 }
 
 // SetLogger to tao
@@ -387,15 +896,17 @@ func SetLogger(configKey string, logger Logger) error {
 	globalLogger.mu.Lock()
 	defer globalLogger.mu.Unlock()
 
-	if globalLogger.loggers == nil {
-		globalLogger.loggers = make(map[string]Logger)
-	}
-
-	if _, ok := globalLogger.loggers[configKey]; ok {
+	old := globalLogger.loggerMap()
+	if _, ok := old[configKey]; ok {
 		return NewError(DuplicateCall, "log: %s's logger has been set before", configKey)
 	}
 
-	globalLogger.loggers[configKey] = logger
+	loggers := make(map[string]Logger, len(old)+1)
+	for k, v := range old {
+		loggers[k] = v
+	}
+	loggers[configKey] = logger
+	globalLogger.loggers.Store(loggers)
 	return nil
 }
 
@@ -404,11 +915,19 @@ func DeleteLogger(configKey string) error {
 	globalLogger.mu.Lock()
 	defer globalLogger.mu.Unlock()
 
-	logger, ok := globalLogger.loggers[configKey]
+	old := globalLogger.loggerMap()
+	logger, ok := old[configKey]
 	if !ok {
 		return NewError(ParamInvalid, "log: %s's logger not set", configKey)
 	}
-	delete(globalLogger.loggers, configKey)
+
+	loggers := make(map[string]Logger, len(old))
+	for k, v := range old {
+		if k != configKey {
+			loggers[k] = v
+		}
+	}
+	globalLogger.loggers.Store(loggers)
 
 	// logger close
 	if l, ok := logger.(io.Closer); ok {
@@ -417,86 +936,238 @@ func DeleteLogger(configKey string) error {
 	return nil
 }
 
-// Debug function wrap of taoLogger
+// SetDefaultLogger of package functions by configKey, which is ConfigKey by default
+// package functions log nothing if no logger of configKey is set
+func SetDefaultLogger(configKey string) {
+	globalLogger.defaultKey.Store(configKey)
+}
+
+// DefaultLogger used by package functions, nil if not set
+func DefaultLogger() Logger {
+	key, ok := globalLogger.defaultKey.Load().(string)
+	if !ok {
+		key = ConfigKey
+	}
+	return GetLogger(key)
+}
+
+// Named child logger of tao's logger, call it after tao init
+// a logger writes to stdout is returned if tao's logger is not a StructuredLogger
+func Named(name string) StructuredLogger {
+	if l, ok := GetLogger(ConfigKey).(StructuredLogger); ok {
+		return l.Named(name)
+	}
+	return NewLogger(name, os.Stdout)
+}
+
+// defaultLogger of package functions, whose frame is skipped for caller
+func defaultLogger() Logger {
+	if l, ok := DefaultLogger().(*logger); ok {
+		return l.wrapped()
+	}
+	return DefaultLogger()
+}
+
+// Debug function wrap of default logger
 func Debug(v ...interface{}) {
-	for _, l := range globalLogger.loggers {
+	if l := defaultLogger(); l != nil {
 		l.Debug(v...)
 	}
 }
 
-// Debugf function wrap of taoLogger
+// Debugf function wrap of default logger
 func Debugf(format string, v ...interface{}) {
-	for _, l := range globalLogger.loggers {
+	if l := defaultLogger(); l != nil {
 		l.Debugf(format, v...)
 	}
 }
 
-// Info function wrap of taoLogger
+// Info function wrap of default logger
 func Info(v ...interface{}) {
-	for _, l := range globalLogger.loggers {
+	if l := defaultLogger(); l != nil {
 		l.Info(v...)
 	}
 }
 
-// Infof function wrap of taoLogger
+// Infof function wrap of default logger
 func Infof(format string, v ...interface{}) {
-	for _, l := range globalLogger.loggers {
+	if l := defaultLogger(); l != nil {
 		l.Infof(format, v...)
 	}
 }
 
-// Warn function wrap of taoLogger
+// Warn function wrap of default logger
 func Warn(v ...interface{}) {
-	for _, l := range globalLogger.loggers {
+	if l := defaultLogger(); l != nil {
 		l.Warn(v...)
 	}
 }
 
-// Warnf function wrap of taoLogger
+// Warnf function wrap of default logger
 func Warnf(format string, v ...interface{}) {
-	for _, l := range globalLogger.loggers {
+	if l := defaultLogger(); l != nil {
 		l.Warnf(format, v...)
 	}
 }
 
-// Error function wrap of taoLogger
+// Error function wrap of default logger
 func Error(v ...interface{}) {
-	for _, l := range globalLogger.loggers {
+	if l := defaultLogger(); l != nil {
 		l.Error(v...)
 	}
 }
 
-// Errorf function wrap of taoLogger
+// Errorf function wrap of default logger
 func Errorf(format string, v ...interface{}) {
-	for _, l := range globalLogger.loggers {
+	if l := defaultLogger(); l != nil {
 		l.Errorf(format, v...)
 	}
 }
 
-// Panic function wrap of taoLogger
+// Panic function wrap of default logger
 func Panic(v ...interface{}) {
-	for _, l := range globalLogger.loggers {
+	if l := defaultLogger(); l != nil {
 		l.Panic(v...)
 	}
 }
 
-// Panicf function wrap of taoLogger
+// Panicf function wrap of default logger
 func Panicf(format string, v ...interface{}) {
-	for _, l := range globalLogger.loggers {
+	if l := defaultLogger(); l != nil {
 		l.Panicf(format, v...)
 	}
 }
 
-// Fatal function wrap of taoLogger
+// Fatal function wrap of default logger
 func Fatal(v ...interface{}) {
-	for _, l := range globalLogger.loggers {
+	if l := defaultLogger(); l != nil {
 		l.Fatal(v...)
 	}
 }
 
-// Fatalf function wrap of taoLogger
+// Fatalf function wrap of default logger
 func Fatalf(format string, v ...interface{}) {
-	for _, l := range globalLogger.loggers {
+	if l := defaultLogger(); l != nil {
 		l.Fatalf(format, v...)
 	}
 }
+
+// Debugw function wrap of default logger
+func Debugw(msg string, keysAndValues ...interface{}) {
+	switch l := defaultLogger().(type) {
+	case StructuredLogger:
+		l.Debugw(msg, keysAndValues...)
+	case Logger:
+		l.Debug(appendFields(msg, keysAndValues))
+	}
+}
+
+// DebugContext function wrap of default logger
+func DebugContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	switch l := defaultLogger().(type) {
+	case StructuredLogger:
+		l.DebugContext(ctx, msg, keysAndValues...)
+	case Logger:
+		l.Debug(appendFields(msg, joinFields(LogFields(ctx), keysAndValues)))
+	}
+}
+
+// Infow function wrap of default logger
+func Infow(msg string, keysAndValues ...interface{}) {
+	switch l := defaultLogger().(type) {
+	case StructuredLogger:
+		l.Infow(msg, keysAndValues...)
+	case Logger:
+		l.Info(appendFields(msg, keysAndValues))
+	}
+}
+
+// InfoContext function wrap of default logger
+func InfoContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	switch l := defaultLogger().(type) {
+	case StructuredLogger:
+		l.InfoContext(ctx, msg, keysAndValues...)
+	case Logger:
+		l.Info(appendFields(msg, joinFields(LogFields(ctx), keysAndValues)))
+	}
+}
+
+// Warnw function wrap of default logger
+func Warnw(msg string, keysAndValues ...interface{}) {
+	switch l := defaultLogger().(type) {
+	case StructuredLogger:
+		l.Warnw(msg, keysAndValues...)
+	case Logger:
+		l.Warn(appendFields(msg, keysAndValues))
+	}
+}
+
+// WarnContext function wrap of default logger
+func WarnContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	switch l := defaultLogger().(type) {
+	case StructuredLogger:
+		l.WarnContext(ctx, msg, keysAndValues...)
+	case Logger:
+		l.Warn(appendFields(msg, joinFields(LogFields(ctx), keysAndValues)))
+	}
+}
+
+// Errorw function wrap of default logger
+func Errorw(msg string, keysAndValues ...interface{}) {
+	switch l := defaultLogger().(type) {
+	case StructuredLogger:
+		l.Errorw(msg, keysAndValues...)
+	case Logger:
+		l.Error(appendFields(msg, keysAndValues))
+	}
+}
+
+// ErrorContext function wrap of default logger
+func ErrorContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	switch l := defaultLogger().(type) {
+	case StructuredLogger:
+		l.ErrorContext(ctx, msg, keysAndValues...)
+	case Logger:
+		l.Error(appendFields(msg, joinFields(LogFields(ctx), keysAndValues)))
+	}
+}
+
+// Panicw function wrap of default logger
+func Panicw(msg string, keysAndValues ...interface{}) {
+	switch l := defaultLogger().(type) {
+	case StructuredLogger:
+		l.Panicw(msg, keysAndValues...)
+	case Logger:
+		l.Panic(appendFields(msg, keysAndValues))
+	}
+}
+
+// PanicContext function wrap of default logger
+func PanicContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	switch l := defaultLogger().(type) {
+	case StructuredLogger:
+		l.PanicContext(ctx, msg, keysAndValues...)
+	case Logger:
+		l.Panic(appendFields(msg, joinFields(LogFields(ctx), keysAndValues)))
+	}
+}
+
+// Fatalw function wrap of default logger
+func Fatalw(msg string, keysAndValues ...interface{}) {
+	switch l := defaultLogger().(type) {
+	case StructuredLogger:
+		l.Fatalw(msg, keysAndValues...)
+	case Logger:
+		l.Fatal(appendFields(msg, keysAndValues))
+	}
+}
+
+// FatalContext function wrap of default logger
+func FatalContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	switch l := defaultLogger().(type) {
+	case StructuredLogger:
+		l.FatalContext(ctx, msg, keysAndValues...)
+	case Logger:
+		l.Fatal(appendFields(msg, joinFields(LogFields(ctx), keysAndValues)))
+	}
+}
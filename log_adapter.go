@@ -0,0 +1,508 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+)
+
+// Adapters between tao's Logger & other logging libraries
+// zap's *SugaredLogger & logrus's *Logger implement Logger already, which can be set by SetLogger directly,
+// others can be adapted by LogFunc with one function instead of all methods of StructuredLogger.
+
+var _ StructuredLogger = LogFunc(nil)
+
+// LogFunc adapts a function to StructuredLogger, panic & fatal are done after it returns
+// e.g. LogFunc(func(level LogLevel, msg string, keysAndValues ...interface{}) { sugar.Infow(msg, keysAndValues...) })
+type LogFunc func(level LogLevel, msg string, keysAndValues ...interface{})
+
+// log by f, then panic or exit in need
+func (f LogFunc) log(level LogLevel, msg string, keysAndValues []interface{}) {
+	f(level, msg, keysAndValues...)
+	switch level {
+	case PANIC:
+		panicLog(levelPrefix[level] + appendFields(msg, keysAndValues))
+	case FATAL:
+		fatalLog()
+	}
+}
+
+// With fields
+func (f LogFunc) With(keysAndValues ...interface{}) StructuredLogger {
+	fields := joinFields(keysAndValues)
+	return LogFunc(func(level LogLevel, msg string, keysAndValues ...interface{}) {
+		f(level, msg, joinFields(fields, keysAndValues)...)
+	})
+}
+
+// Named child logger, whose name is logged as field logger
+func (f LogFunc) Named(name string) StructuredLogger {
+	return f.With("logger", name)
+}
+
+// Debug logs info in debug level
+func (f LogFunc) Debug(v ...interface{}) {
+	f.log(DEBUG, strings.TrimSuffix(fmt.Sprintln(v...), "\n"), nil)
+}
+
+// Debugf logs info in debug level
+func (f LogFunc) Debugf(format string, v ...interface{}) {
+	f.log(DEBUG, fmt.Sprintf(format, v...), nil)
+}
+
+// Debugw logs message & fields in debug level
+func (f LogFunc) Debugw(msg string, keysAndValues ...interface{}) {
+	f.log(DEBUG, msg, keysAndValues)
+}
+
+// DebugContext logs message & fields with fields of ctx in debug level
+func (f LogFunc) DebugContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	f.log(DEBUG, msg, joinFields(LogFields(ctx), keysAndValues))
+}
+
+// Info logs info in info level
+func (f LogFunc) Info(v ...interface{}) {
+	f.log(INFO, strings.TrimSuffix(fmt.Sprintln(v...), "\n"), nil)
+}
+
+// Infof logs info in info level
+func (f LogFunc) Infof(format string, v ...interface{}) {
+	f.log(INFO, fmt.Sprintf(format, v...), nil)
+}
+
+// Infow logs message & fields in info level
+func (f LogFunc) Infow(msg string, keysAndValues ...interface{}) {
+	f.log(INFO, msg, keysAndValues)
+}
+
+// InfoContext logs message & fields with fields of ctx in info level
+func (f LogFunc) InfoContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	f.log(INFO, msg, joinFields(LogFields(ctx), keysAndValues))
+}
+
+// Warn logs info in warn level
+func (f LogFunc) Warn(v ...interface{}) {
+	f.log(WARNING, strings.TrimSuffix(fmt.Sprintln(v...), "\n"), nil)
+}
+
+// Warnf logs info in warn level
+func (f LogFunc) Warnf(format string, v ...interface{}) {
+	f.log(WARNING, fmt.Sprintf(format, v...), nil)
+}
+
+// Warnw logs message & fields in warn level
+func (f LogFunc) Warnw(msg string, keysAndValues ...interface{}) {
+	f.log(WARNING, msg, keysAndValues)
+}
+
+// WarnContext logs message & fields with fields of ctx in warn level
+func (f LogFunc) WarnContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	f.log(WARNING, msg, joinFields(LogFields(ctx), keysAndValues))
+}
+
+// Error logs info in error level
+func (f LogFunc) Error(v ...interface{}) {
+	f.log(ERROR, strings.TrimSuffix(fmt.Sprintln(v...), "\n"), nil)
+}
+
+// Errorf logs info in error level
+func (f LogFunc) Errorf(format string, v ...interface{}) {
+	f.log(ERROR, fmt.Sprintf(format, v...), nil)
+}
+
+// Errorw logs message & fields in error level
+func (f LogFunc) Errorw(msg string, keysAndValues ...interface{}) {
+	f.log(ERROR, msg, keysAndValues)
+}
+
+// ErrorContext logs message & fields with fields of ctx in error level
+func (f LogFunc) ErrorContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	f.log(ERROR, msg, joinFields(LogFields(ctx), keysAndValues))
+}
+
+// Panic logs info in panic level
+func (f LogFunc) Panic(v ...interface{}) {
+	f.log(PANIC, strings.TrimSuffix(fmt.Sprintln(v...), "\n"), nil)
+}
+
+// Panicf logs info in panic level
+func (f LogFunc) Panicf(format string, v ...interface{}) {
+	f.log(PANIC, fmt.Sprintf(format, v...), nil)
+}
+
+// Panicw logs message & fields in panic level
+func (f LogFunc) Panicw(msg string, keysAndValues ...interface{}) {
+	f.log(PANIC, msg, keysAndValues)
+}
+
+// PanicContext logs message & fields with fields of ctx in panic level
+func (f LogFunc) PanicContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	f.log(PANIC, msg, joinFields(LogFields(ctx), keysAndValues))
+}
+
+// Fatal logs info in fatal level
+func (f LogFunc) Fatal(v ...interface{}) {
+	f.log(FATAL, strings.TrimSuffix(fmt.Sprintln(v...), "\n"), nil)
+}
+
+// Fatalf logs info in fatal level
+func (f LogFunc) Fatalf(format string, v ...interface{}) {
+	f.log(FATAL, fmt.Sprintf(format, v...), nil)
+}
+
+// Fatalw logs message & fields in fatal level
+func (f LogFunc) Fatalw(msg string, keysAndValues ...interface{}) {
+	f.log(FATAL, msg, keysAndValues)
+}
+
+// FatalContext logs message & fields with fields of ctx in fatal level
+func (f LogFunc) FatalContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	f.log(FATAL, msg, joinFields(LogFields(ctx), keysAndValues))
+}
+
+// slog levels of PANIC & FATAL, which are above slog.LevelError
+const (
+	slogLevelPanic = slog.LevelError + 4
+	slogLevelFatal = slog.LevelError + 8
+)
+
+// slogLevel of level
+func slogLevel(level LogLevel) slog.Level {
+	switch level {
+	case DEBUG:
+		return slog.LevelDebug
+	case INFO:
+		return slog.LevelInfo
+	case WARNING:
+		return slog.LevelWarn
+	case ERROR:
+		return slog.LevelError
+	case PANIC:
+		return slogLevelPanic
+	default:
+		return slogLevelFatal
+	}
+}
+
+// logLevel of slog level, levels above error are error
+func logLevel(level slog.Level) LogLevel {
+	switch {
+	case level < slog.LevelInfo:
+		return DEBUG
+	case level < slog.LevelWarn:
+		return INFO
+	case level < slog.LevelError:
+		return WARNING
+	default:
+		return ERROR
+	}
+}
+
+// NewSlogLogger of h, panic & fatal are logged in levels above slog.LevelError
+func NewSlogLogger(h slog.Handler) StructuredLogger {
+	return &slogLogger{h: h}
+}
+
+var _ StructuredLogger = (*slogLogger)(nil)
+
+// slogLogger logs by slog.Handler
+type slogLogger struct {
+	h    slog.Handler
+	name string
+}
+
+// log record to handler, then panic or exit in need
+func (s *slogLogger) log(ctx context.Context, level LogLevel, msg string, keysAndValues []interface{}) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if s.h.Enabled(ctx, slogLevel(level)) {
+		var pcs [1]uintptr
+		// skip Callers, log & method of slogLogger
+		runtime.Callers(3, pcs[:])
+		r := slog.NewRecord(GetClock().Now(), slogLevel(level), msg, pcs[0])
+		if s.name != "" {
+			r.AddAttrs(slog.String("logger", s.name))
+		}
+		r.Add(keysAndValues...)
+		_ = s.h.Handle(ctx, r)
+	}
+
+	switch level {
+	case PANIC:
+		panicLog(levelPrefix[level] + appendFields(msg, keysAndValues))
+	case FATAL:
+		fatalLog()
+	}
+}
+
+// With fields
+func (s *slogLogger) With(keysAndValues ...interface{}) StructuredLogger {
+	r := slog.Record{}
+	r.Add(keysAndValues...)
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return &slogLogger{h: s.h.WithAttrs(attrs), name: s.name}
+}
+
+// Named child logger, whose name is logged as attr logger
+func (s *slogLogger) Named(name string) StructuredLogger {
+	if s.name != "" {
+		name = s.name + "." + name
+	}
+	return &slogLogger{h: s.h, name: name}
+}
+
+// Debug logs info in debug level
+func (s *slogLogger) Debug(v ...interface{}) {
+	s.log(context.Background(), DEBUG, strings.TrimSuffix(fmt.Sprintln(v...), "\n"), nil)
+}
+
+// Debugf logs info in debug level
+func (s *slogLogger) Debugf(format string, v ...interface{}) {
+	s.log(context.Background(), DEBUG, fmt.Sprintf(format, v...), nil)
+}
+
+// Debugw logs message & fields in debug level
+func (s *slogLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	s.log(context.Background(), DEBUG, msg, keysAndValues)
+}
+
+// DebugContext logs message & fields with fields of ctx in debug level
+func (s *slogLogger) DebugContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	s.log(ctx, DEBUG, msg, joinFields(LogFields(ctx), keysAndValues))
+}
+
+// Info logs info in info level
+func (s *slogLogger) Info(v ...interface{}) {
+	s.log(context.Background(), INFO, strings.TrimSuffix(fmt.Sprintln(v...), "\n"), nil)
+}
+
+// Infof logs info in info level
+func (s *slogLogger) Infof(format string, v ...interface{}) {
+	s.log(context.Background(), INFO, fmt.Sprintf(format, v...), nil)
+}
+
+// Infow logs message & fields in info level
+func (s *slogLogger) Infow(msg string, keysAndValues ...interface{}) {
+	s.log(context.Background(), INFO, msg, keysAndValues)
+}
+
+// InfoContext logs message & fields with fields of ctx in info level
+func (s *slogLogger) InfoContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	s.log(ctx, INFO, msg, joinFields(LogFields(ctx), keysAndValues))
+}
+
+// Warn logs info in warn level
+func (s *slogLogger) Warn(v ...interface{}) {
+	s.log(context.Background(), WARNING, strings.TrimSuffix(fmt.Sprintln(v...), "\n"), nil)
+}
+
+// Warnf logs info in warn level
+func (s *slogLogger) Warnf(format string, v ...interface{}) {
+	s.log(context.Background(), WARNING, fmt.Sprintf(format, v...), nil)
+}
+
+// Warnw logs message & fields in warn level
+func (s *slogLogger) Warnw(msg string, keysAndValues ...interface{}) {
+	s.log(context.Background(), WARNING, msg, keysAndValues)
+}
+
+// WarnContext logs message & fields with fields of ctx in warn level
+func (s *slogLogger) WarnContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	s.log(ctx, WARNING, msg, joinFields(LogFields(ctx), keysAndValues))
+}
+
+// Error logs info in error level
+func (s *slogLogger) Error(v ...interface{}) {
+	s.log(context.Background(), ERROR, strings.TrimSuffix(fmt.Sprintln(v...), "\n"), nil)
+}
+
+// Errorf logs info in error level
+func (s *slogLogger) Errorf(format string, v ...interface{}) {
+	s.log(context.Background(), ERROR, fmt.Sprintf(format, v...), nil)
+}
+
+// Errorw logs message & fields in error level
+func (s *slogLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	s.log(context.Background(), ERROR, msg, keysAndValues)
+}
+
+// ErrorContext logs message & fields with fields of ctx in error level
+func (s *slogLogger) ErrorContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	s.log(ctx, ERROR, msg, joinFields(LogFields(ctx), keysAndValues))
+}
+
+// Panic logs info in panic level
+func (s *slogLogger) Panic(v ...interface{}) {
+	s.log(context.Background(), PANIC, strings.TrimSuffix(fmt.Sprintln(v...), "\n"), nil)
+}
+
+// Panicf logs info in panic level
+func (s *slogLogger) Panicf(format string, v ...interface{}) {
+	s.log(context.Background(), PANIC, fmt.Sprintf(format, v...), nil)
+}
+
+// Panicw logs message & fields in panic level
+func (s *slogLogger) Panicw(msg string, keysAndValues ...interface{}) {
+	s.log(context.Background(), PANIC, msg, keysAndValues)
+}
+
+// PanicContext logs message & fields with fields of ctx in panic level
+func (s *slogLogger) PanicContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	s.log(ctx, PANIC, msg, joinFields(LogFields(ctx), keysAndValues))
+}
+
+// Fatal logs info in fatal level
+func (s *slogLogger) Fatal(v ...interface{}) {
+	s.log(context.Background(), FATAL, strings.TrimSuffix(fmt.Sprintln(v...), "\n"), nil)
+}
+
+// Fatalf logs info in fatal level
+func (s *slogLogger) Fatalf(format string, v ...interface{}) {
+	s.log(context.Background(), FATAL, fmt.Sprintf(format, v...), nil)
+}
+
+// Fatalw logs message & fields in fatal level
+func (s *slogLogger) Fatalw(msg string, keysAndValues ...interface{}) {
+	s.log(context.Background(), FATAL, msg, keysAndValues)
+}
+
+// FatalContext logs message & fields with fields of ctx in fatal level
+func (s *slogLogger) FatalContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	s.log(ctx, FATAL, msg, joinFields(LogFields(ctx), keysAndValues))
+}
+
+// NewSlogHandler of tao's logging, so that libraries using slog write through sinks & levels of l
+// records are written to DefaultLogger at the time if l is nil, levels above error are logged in error level
+func NewSlogHandler(l Logger) slog.Handler {
+	return &slogHandler{l: l}
+}
+
+var _ slog.Handler = (*slogHandler)(nil)
+
+// slogHandler writes records to Logger
+type slogHandler struct {
+	l      Logger
+	fields []interface{}
+	group  string
+}
+
+// logger of handler
+func (h *slogHandler) logger() Logger {
+	if h.l != nil {
+		return h.l
+	}
+	return DefaultLogger()
+}
+
+// Enabled by level of logger
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	switch l := h.logger().(type) {
+	case nil:
+		return false
+	case *logger:
+		return l.enabled(logLevel(level))
+	default:
+		return true
+	}
+}
+
+// Handle record by logger, caller of record is used by logger created by tao
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	fields := joinFields(h.fields)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, h.group, a)
+		return true
+	})
+	level := logLevel(r.Level)
+
+	switch l := h.logger().(type) {
+	case nil:
+	case *logger:
+		if l.sampler != nil && !l.sampler.allow(l.key, level, r.Message) {
+			return nil
+		}
+		e := &logEntry{
+			time:    r.Time,
+			level:   level,
+			key:     l.key,
+			message: r.Message,
+			fields:  joinFields(l.fields, fields),
+		}
+		if e.time.IsZero() {
+			e.time = GetClock().Now()
+		}
+		if r.PC != 0 && l.needCaller() {
+			frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+			e.file, e.line = frame.File, frame.Line
+		}
+		l.emit(e)
+	default:
+		logToLogger(l, level, r.Message, fields)
+	}
+	return nil
+}
+
+// WithAttrs logged in every record
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := joinFields(h.fields)
+	for _, a := range attrs {
+		fields = appendAttr(fields, h.group, a)
+	}
+	return &slogHandler{l: h.l, fields: fields, group: h.group}
+}
+
+// WithGroup of attrs, keys of which are prefixed by group & dot
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{l: h.l, fields: h.fields, group: joinGroup(h.group, name)}
+}
+
+// appendAttr to fields, attrs of group are flattened by dot
+func appendAttr(fields []interface{}, group string, a slog.Attr) []interface{} {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		// inline group without key
+		if a.Key != "" {
+			group = joinGroup(group, a.Key)
+		}
+		for _, ga := range a.Value.Group() {
+			fields = appendAttr(fields, group, ga)
+		}
+		return fields
+	}
+	return append(fields, joinGroup(group, a.Key), a.Value.Any())
+}
+
+// joinGroup & key by dot
+func joinGroup(group, key string) string {
+	if group == "" {
+		return key
+	}
+	return group + "." + key
+}
@@ -0,0 +1,124 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogFunc(t *testing.T) {
+	lines := make([]string, 0)
+	var f LogFunc = func(level LogLevel, msg string, keysAndValues ...interface{}) {
+		lines = append(lines, fmt.Sprint(level, " ", msg, keysAndValues))
+	}
+
+	var l StructuredLogger = f
+	l.Debug("a", 1)
+	l.Infof("%d", 2)
+	l.With("k", "v").Named("redis").Warnw("w", "n", 3)
+	l.ErrorContext(WithLogFields(context.Background(), "trace", "abc"), "e")
+	assert.PanicsWithValue(t, "[P] p a=1", func() {
+		l.Panicw("p", "a", 1)
+	})
+	assert.Equal(t, []string{
+		"debug a 1[]",
+		"info 2[]",
+		"warning w[k v logger redis n 3]",
+		"error e[trace abc]",
+		"panic p[a 1]",
+	}, lines)
+}
+
+func TestSlogLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h := slog.NewTextHandler(buf, &slog.HandlerOptions{
+		Level:     slog.LevelDebug,
+		AddSource: true,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			switch a.Key {
+			case slog.TimeKey:
+				return slog.Attr{}
+			case slog.SourceKey:
+				source := a.Value.Any().(*slog.Source)
+				return slog.Bool("caller", strings.HasSuffix(source.File, "log_adapter_test.go"))
+			}
+			return a
+		},
+	})
+	l := NewSlogLogger(h)
+
+	l.Debugf("debug %d", 1)
+	l.With("k", "v").Named("redis").Named("pool").Infow("info", "n", 2)
+	l.WarnContext(WithLogFields(context.Background(), "trace", "abc"), "warn")
+	l.Error("error")
+	assert.Panics(t, func() {
+		l.Panic("panic")
+	})
+	assert.Equal(t, `level=DEBUG caller=true msg="debug 1"
+level=INFO caller=true msg=info k=v logger=redis.pool n=2
+level=WARN caller=true msg=warn trace=abc
+level=ERROR caller=true msg=error
+level=ERROR+4 caller=true msg=panic
+`, buf.String())
+}
+
+func TestSlogHandler(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := newLogger("lib", LogFlag(log.Lshortfile), 2, &logOutput{w: buf})
+
+	t.Run("Logger", func(t *testing.T) {
+		sl := slog.New(NewSlogHandler(l)).With("a", 1).WithGroup("g")
+		sl.Info("info", "k", "v", slog.Group("sub", "x", 1), slog.Group("", "inline", true))
+		sl.Log(context.Background(), slog.LevelError+8, "fatal is logged in error level")
+		assert.True(t, strings.HasPrefix(buf.String(), "log_adapter_test.go:"))
+		assert.Contains(t, buf.String(), ": [I] info a=1 g.k=v g.sub.x=1 g.inline=true\n")
+		assert.Contains(t, buf.String(), ": [E] fatal is logged in error level a=1\n")
+		buf.Reset()
+
+		assert.Nil(t, SetLogLevel("lib", WARNING))
+		defer ResetLogLevel("lib")
+		sl.Info("ignored")
+		assert.Empty(t, buf.String())
+		assert.False(t, sl.Enabled(context.Background(), slog.LevelInfo))
+		assert.True(t, sl.Enabled(context.Background(), slog.LevelWarn))
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		assert.Nil(t, SetLogger("slog", plainLogger{newLogger("", 0, 2, &logOutput{w: buf})}))
+		SetDefaultLogger("slog")
+		defer func() {
+			SetDefaultLogger(ConfigKey)
+			assert.Nil(t, DeleteLogger("slog"))
+		}()
+
+		sl := slog.New(NewSlogHandler(nil))
+		sl.Warn("warn", "k", "v")
+		assert.Equal(t, "[W] warn k=v\n", buf.String())
+		buf.Reset()
+
+		SetDefaultLogger("none")
+		assert.False(t, sl.Enabled(context.Background(), slog.LevelError))
+		sl.Error("nothing")
+		assert.Empty(t, buf.String())
+	})
+}
@@ -0,0 +1,270 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Async config of console & file log, lines are written by a goroutine from a ring buffer
+type Async struct {
+	// Size of ring buffer in lines
+	Size int `json:"size"`
+	// Policy when ring buffer is full
+	Policy AsyncPolicy `json:"policy"`
+	// FlushInterval in milliseconds to flush the underlying writers
+	FlushInterval int `json:"flush_interval"`
+}
+
+// AsyncPolicy when ring buffer is full
+type AsyncPolicy uint8
+
+const (
+	// Block the caller until there is room
+	Block AsyncPolicy = iota
+	// Drop the line & count it
+	Drop
+)
+
+// String for AsyncPolicy Config
+func (p AsyncPolicy) String() string {
+	switch p {
+	case Block:
+		return "block"
+	case Drop:
+		return "drop"
+	default:
+		return fmt.Sprintf("tao.AsyncPolicy(%d)", p)
+	}
+}
+
+// MarshalText instead of number
+func (p AsyncPolicy) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText to number
+func (p *AsyncPolicy) UnmarshalText(text []byte) error {
+	switch lower := string(bytes.ToLower(text)); lower {
+	case "block", "":
+		*p = Block
+	case "drop":
+		*p = Drop
+	default:
+		return fmt.Errorf("log: unrecognized AsyncPolicy: %q", lower)
+	}
+	return nil
+}
+
+var _ io.WriteCloser = (*asyncWriter)(nil)
+
+// asyncWriter writes lines to w from a bounded ring buffer
+type asyncWriter struct {
+	w      io.Writer
+	policy AsyncPolicy
+
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	idle     *sync.Cond
+
+	ring    [][]byte
+	head    int
+	n       int
+	writing bool
+	closed  bool
+
+	dropped uint64
+	done    chan struct{}
+	stop    chan struct{}
+}
+
+// asyncWriters alive, which are flushed by FlushLogs
+var asyncWriters sync.Map
+
+// newAsyncWriter of w, drained by a goroutine until closed
+func newAsyncWriter(w io.Writer, async *Async) *asyncWriter {
+	size, interval := async.Size, async.FlushInterval
+	if size <= 0 {
+		size = 1024
+	}
+	if interval <= 0 {
+		interval = 1000
+	}
+
+	a := &asyncWriter{
+		w:      w,
+		policy: async.Policy,
+		ring:   make([][]byte, size),
+		done:   make(chan struct{}),
+		stop:   make(chan struct{}),
+	}
+	a.notEmpty = sync.NewCond(&a.mu)
+	a.notFull = sync.NewCond(&a.mu)
+	a.idle = sync.NewCond(&a.mu)
+	asyncWriters.Store(a, struct{}{})
+
+	go a.run()
+	go func() {
+		ticker := time.NewTicker(time.Duration(interval) * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				_ = a.Flush()
+			case <-a.stop:
+				return
+			}
+		}
+	}()
+	return a
+}
+
+// Write p into ring buffer, which is written to w directly after closed
+func (a *asyncWriter) Write(p []byte) (int, error) {
+	a.mu.Lock()
+	for a.n == len(a.ring) && a.policy == Block && !a.closed {
+		a.notFull.Wait()
+	}
+	if a.closed {
+		a.mu.Unlock()
+		return a.w.Write(p)
+	}
+	if a.n == len(a.ring) {
+		a.mu.Unlock()
+		atomic.AddUint64(&a.dropped, 1)
+		return len(p), nil
+	}
+
+	line := make([]byte, len(p))
+	copy(line, p)
+	a.ring[(a.head+a.n)%len(a.ring)] = line
+	a.n++
+	a.notEmpty.Signal()
+	a.mu.Unlock()
+	return len(p), nil
+}
+
+// run drains ring buffer until closed
+func (a *asyncWriter) run() {
+	defer close(a.done)
+
+	batch := make([][]byte, 0, len(a.ring))
+	for {
+		a.mu.Lock()
+		for a.n == 0 && !a.closed {
+			a.notEmpty.Wait()
+		}
+		if a.n == 0 {
+			a.mu.Unlock()
+			return
+		}
+		batch = batch[:0]
+		for ; a.n > 0; a.n-- {
+			batch = append(batch, a.ring[a.head])
+			a.ring[a.head] = nil
+			a.head = (a.head + 1) % len(a.ring)
+		}
+		a.writing = true
+		a.notFull.Broadcast()
+		a.mu.Unlock()
+
+		for _, line := range batch {
+			_, _ = a.w.Write(line)
+		}
+
+		a.mu.Lock()
+		a.writing = false
+		a.idle.Broadcast()
+		a.mu.Unlock()
+	}
+}
+
+// Dropped lines because ring buffer is full
+func (a *asyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&a.dropped)
+}
+
+// Flush waits until lines buffered are written, then flushes w
+func (a *asyncWriter) Flush() error {
+	a.mu.Lock()
+	for (a.n > 0 || a.writing) && !a.closed {
+		a.idle.Wait()
+	}
+	a.mu.Unlock()
+	return flushWriter(a.w)
+}
+
+// flushWriter which can be flushed or synced
+func flushWriter(w io.Writer) error {
+	switch f := w.(type) {
+	case interface{ Flush() error }:
+		return f.Flush()
+	case *os.File:
+		// stdout & stderr may not support sync
+		if f == os.Stdout || f == os.Stderr {
+			return nil
+		}
+		return f.Sync()
+	case interface{ Sync() error }:
+		return f.Sync()
+	}
+	return nil
+}
+
+// Close after lines buffered are written, w is not closed
+func (a *asyncWriter) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	a.notEmpty.Broadcast()
+	a.notFull.Broadcast()
+	a.idle.Broadcast()
+	a.mu.Unlock()
+
+	<-a.done
+	close(a.stop)
+	asyncWriters.Delete(a)
+	return flushWriter(a.w)
+}
+
+// FlushLogs buffered by async writers of tao, called before panic, fatal & shutdown
+func FlushLogs() (err error) {
+	asyncWriters.Range(func(key, _ interface{}) bool {
+		if e := key.(*asyncWriter).Flush(); e != nil && err == nil {
+			err = e
+		}
+		return true
+	})
+	return
+}
+
+// DroppedLogs of async writers of tao because ring buffers are full
+func DroppedLogs() (dropped uint64) {
+	asyncWriters.Range(func(key, _ interface{}) bool {
+		dropped += key.(*asyncWriter).Dropped()
+		return true
+	})
+	return
+}
@@ -0,0 +1,153 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gateWriter blocks writes until released
+type gateWriter struct {
+	release chan struct{}
+
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	flushed int
+}
+
+func (g *gateWriter) Write(p []byte) (int, error) {
+	<-g.release
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.buf.Write(p)
+}
+
+func (g *gateWriter) Flush() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.flushed++
+	return nil
+}
+
+func (g *gateWriter) Flushed() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.flushed
+}
+
+func (g *gateWriter) String() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.buf.String()
+}
+
+func TestAsyncWriter(t *testing.T) {
+	// drainer has taken all lines & is writing
+	writing := func(a *asyncWriter) func() bool {
+		return func() bool {
+			a.mu.Lock()
+			defer a.mu.Unlock()
+			return a.n == 0 && a.writing
+		}
+	}
+
+	t.Run("Config", func(t *testing.T) {
+		async := new(Async)
+		assert.Nil(t, json.Unmarshal([]byte(`{"size":10,"policy":"drop","flush_interval":100}`), async))
+		assert.Equal(t, Async{Size: 10, Policy: Drop, FlushInterval: 100}, *async)
+		assert.NotNil(t, json.Unmarshal([]byte(`{"policy":"wait"}`), async))
+
+		marshal, err := json.Marshal(Block)
+		assert.Nil(t, err)
+		assert.Equal(t, `"block"`, string(marshal))
+		t.Log(AsyncPolicy(2))
+	})
+
+	t.Run("Drop", func(t *testing.T) {
+		g := &gateWriter{release: make(chan struct{})}
+		a := newAsyncWriter(g, &Async{Size: 2, Policy: Drop})
+		defer a.Close()
+
+		_, _ = a.Write([]byte("1\n"))
+		assert.Eventually(t, writing(a), time.Second, time.Millisecond)
+		_, _ = a.Write([]byte("2\n"))
+		_, _ = a.Write([]byte("3\n"))
+		n, err := a.Write([]byte("4\n"))
+		assert.Nil(t, err)
+		assert.Equal(t, 2, n)
+		assert.Equal(t, uint64(1), a.Dropped())
+		assert.GreaterOrEqual(t, DroppedLogs(), uint64(1))
+
+		close(g.release)
+		assert.Nil(t, a.Flush())
+		assert.Equal(t, "1\n2\n3\n", g.String())
+		assert.Equal(t, 1, g.Flushed())
+	})
+
+	t.Run("Block", func(t *testing.T) {
+		g := &gateWriter{release: make(chan struct{})}
+		a := newAsyncWriter(g, &Async{Size: 1, FlushInterval: 10})
+
+		_, _ = a.Write([]byte("1\n"))
+		assert.Eventually(t, writing(a), time.Second, time.Millisecond)
+		_, _ = a.Write([]byte("2\n"))
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _ = a.Write([]byte("3\n"))
+		}()
+		select {
+		case <-done:
+			t.Fatal("write should be blocked")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(g.release)
+		<-done
+		assert.Nil(t, a.Close())
+		assert.Equal(t, "1\n2\n3\n", g.String())
+		assert.Equal(t, uint64(0), a.Dropped())
+		assert.Greater(t, g.Flushed(), 0)
+
+		// written directly after closed
+		_, _ = a.Write([]byte("4\n"))
+		assert.Equal(t, "1\n2\n3\n4\n", g.String())
+		assert.Nil(t, a.Close())
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		g := &gateWriter{release: make(chan struct{})}
+		a := newAsyncWriter(g, &Async{})
+		defer a.Close()
+		l := newLogger("async", 0, 2, &logOutput{w: a})
+
+		l.Info("info")
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			close(g.release)
+		}()
+		assert.Panics(t, func() {
+			l.Panic("panic")
+		})
+		// flushed before panic
+		assert.Equal(t, "[I] info\n[P] panic\n", g.String())
+	})
+}
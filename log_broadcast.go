@@ -0,0 +1,250 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+var _ StructuredLogger = (*broadcastLogger)(nil)
+
+// broadcastLogger logs to all loggers set by SetLogger
+type broadcastLogger struct {
+	name   string
+	fields []interface{}
+}
+
+// Broadcast logger which logs to all loggers set by SetLogger in order of their keys
+// panic & fatal are done once after all loggers have written,
+// Fatal of a Logger not created by tao is logged in error level instead, because it exits
+func Broadcast() StructuredLogger {
+	return new(broadcastLogger)
+}
+
+// log to all loggers, then panic or exit in need
+func (b *broadcastLogger) log(level LogLevel, msg string, keysAndValues []interface{}) {
+	loggers := globalLogger.loggerMap()
+	keys := make([]string, 0, len(loggers))
+	for k := range loggers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := joinFields(b.fields, keysAndValues)
+	line := ""
+	for _, k := range keys {
+		switch l := loggers[k].(type) {
+		case *logger:
+			if b.name != "" {
+				l = l.Named(b.name).(*logger)
+			}
+			// frame of broadcastLogger
+			l = l.wrapped()
+			if !l.enabled(level) {
+				continue
+			}
+			out := l.output(level, "", msg, fields)
+			if line == "" {
+				line = out
+			}
+		case StructuredLogger:
+			if b.name != "" {
+				l = l.Named(b.name)
+			}
+			logToLogger(l, level, msg, fields)
+		default:
+			logToLogger(l, level, msg, fields)
+		}
+	}
+
+	switch level {
+	case PANIC:
+		if line == "" {
+			line = levelPrefix[level] + appendFields(msg, fields)
+		}
+		panicLog(line)
+	case FATAL:
+		fatalLog()
+	}
+}
+
+// logToLogger not created by tao, fatal is logged in error level & panic is recovered
+func logToLogger(l Logger, level LogLevel, msg string, keysAndValues []interface{}) {
+	if sl, ok := l.(StructuredLogger); ok {
+		logTo(level, msg, keysAndValues, sl.Debugw, sl.Infow, sl.Warnw, sl.Errorw, sl.Panicw)
+		return
+	}
+	logTo(level, msg, keysAndValues,
+		func(msg string, keysAndValues ...interface{}) { l.Debug(appendFields(msg, keysAndValues)) },
+		func(msg string, keysAndValues ...interface{}) { l.Info(appendFields(msg, keysAndValues)) },
+		func(msg string, keysAndValues ...interface{}) { l.Warn(appendFields(msg, keysAndValues)) },
+		func(msg string, keysAndValues ...interface{}) { l.Error(appendFields(msg, keysAndValues)) },
+		func(msg string, keysAndValues ...interface{}) { l.Panic(appendFields(msg, keysAndValues)) })
+}
+
+// logTo one logger by its functions of level
+func logTo(level LogLevel, msg string, keysAndValues []interface{}, debugw, infow, warnw, errorw, panicw func(msg string, keysAndValues ...interface{})) {
+	switch level {
+	case DEBUG:
+		debugw(msg, keysAndValues...)
+	case INFO:
+		infow(msg, keysAndValues...)
+	case WARNING:
+		warnw(msg, keysAndValues...)
+	case ERROR, FATAL:
+		errorw(msg, keysAndValues...)
+	case PANIC:
+		// panic once after all loggers
+		defer func() {
+			_ = recover()
+		}()
+		panicw(msg, keysAndValues...)
+	}
+}
+
+// With fields
+func (b *broadcastLogger) With(keysAndValues ...interface{}) StructuredLogger {
+	return &broadcastLogger{name: b.name, fields: joinFields(b.fields, keysAndValues)}
+}
+
+// Named child logger of every logger
+func (b *broadcastLogger) Named(name string) StructuredLogger {
+	if b.name != "" {
+		name = b.name + "." + name
+	}
+	return &broadcastLogger{name: name, fields: b.fields}
+}
+
+// Debug logs info in debug level
+func (b *broadcastLogger) Debug(v ...interface{}) {
+	b.log(DEBUG, fmt.Sprintln(v...), nil)
+}
+
+// Debugf logs info in debug level
+func (b *broadcastLogger) Debugf(format string, v ...interface{}) {
+	b.log(DEBUG, fmt.Sprintf(format, v...), nil)
+}
+
+// Debugw logs message & fields in debug level
+func (b *broadcastLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	b.log(DEBUG, msg, keysAndValues)
+}
+
+// DebugContext logs message & fields with fields of ctx in debug level
+func (b *broadcastLogger) DebugContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	b.log(DEBUG, msg, joinFields(LogFields(ctx), keysAndValues))
+}
+
+// Info logs info in info level
+func (b *broadcastLogger) Info(v ...interface{}) {
+	b.log(INFO, fmt.Sprintln(v...), nil)
+}
+
+// Infof logs info in info level
+func (b *broadcastLogger) Infof(format string, v ...interface{}) {
+	b.log(INFO, fmt.Sprintf(format, v...), nil)
+}
+
+// Infow logs message & fields in info level
+func (b *broadcastLogger) Infow(msg string, keysAndValues ...interface{}) {
+	b.log(INFO, msg, keysAndValues)
+}
+
+// InfoContext logs message & fields with fields of ctx in info level
+func (b *broadcastLogger) InfoContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	b.log(INFO, msg, joinFields(LogFields(ctx), keysAndValues))
+}
+
+// Warn logs info in warn level
+func (b *broadcastLogger) Warn(v ...interface{}) {
+	b.log(WARNING, fmt.Sprintln(v...), nil)
+}
+
+// Warnf logs info in warn level
+func (b *broadcastLogger) Warnf(format string, v ...interface{}) {
+	b.log(WARNING, fmt.Sprintf(format, v...), nil)
+}
+
+// Warnw logs message & fields in warn level
+func (b *broadcastLogger) Warnw(msg string, keysAndValues ...interface{}) {
+	b.log(WARNING, msg, keysAndValues)
+}
+
+// WarnContext logs message & fields with fields of ctx in warn level
+func (b *broadcastLogger) WarnContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	b.log(WARNING, msg, joinFields(LogFields(ctx), keysAndValues))
+}
+
+// Error logs info in error level
+func (b *broadcastLogger) Error(v ...interface{}) {
+	b.log(ERROR, fmt.Sprintln(v...), nil)
+}
+
+// Errorf logs info in error level
+func (b *broadcastLogger) Errorf(format string, v ...interface{}) {
+	b.log(ERROR, fmt.Sprintf(format, v...), nil)
+}
+
+// Errorw logs message & fields in error level
+func (b *broadcastLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	b.log(ERROR, msg, keysAndValues)
+}
+
+// ErrorContext logs message & fields with fields of ctx in error level
+func (b *broadcastLogger) ErrorContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	b.log(ERROR, msg, joinFields(LogFields(ctx), keysAndValues))
+}
+
+// Panic logs info in panic level
+func (b *broadcastLogger) Panic(v ...interface{}) {
+	b.log(PANIC, fmt.Sprintln(v...), nil)
+}
+
+// Panicf logs info in panic level
+func (b *broadcastLogger) Panicf(format string, v ...interface{}) {
+	b.log(PANIC, fmt.Sprintf(format, v...), nil)
+}
+
+// Panicw logs message & fields in panic level
+func (b *broadcastLogger) Panicw(msg string, keysAndValues ...interface{}) {
+	b.log(PANIC, msg, keysAndValues)
+}
+
+// PanicContext logs message & fields with fields of ctx in panic level
+func (b *broadcastLogger) PanicContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	b.log(PANIC, msg, joinFields(LogFields(ctx), keysAndValues))
+}
+
+// Fatal logs info in fatal level
+func (b *broadcastLogger) Fatal(v ...interface{}) {
+	b.log(FATAL, fmt.Sprintln(v...), nil)
+}
+
+// Fatalf logs info in fatal level
+func (b *broadcastLogger) Fatalf(format string, v ...interface{}) {
+	b.log(FATAL, fmt.Sprintf(format, v...), nil)
+}
+
+// Fatalw logs message & fields in fatal level
+func (b *broadcastLogger) Fatalw(msg string, keysAndValues ...interface{}) {
+	b.log(FATAL, msg, keysAndValues)
+}
+
+// FatalContext logs message & fields with fields of ctx in fatal level
+func (b *broadcastLogger) FatalContext(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	b.log(FATAL, msg, joinFields(LogFields(ctx), keysAndValues))
+}
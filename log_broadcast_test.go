@@ -0,0 +1,107 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroadcast(t *testing.T) {
+	a, b, c := new(bytes.Buffer), new(bytes.Buffer), new(bytes.Buffer)
+	// calldepth of logger called by broadcastLogger
+	assert.Nil(t, SetLogger("broadcast.a", newLogger("a", 0, 3, &logOutput{w: a})))
+	assert.Nil(t, SetLogger("broadcast.b", newLogger("b", 0, 3, &logOutput{w: b})))
+	assert.Nil(t, SetLogger("broadcast.c", plainLogger{newLogger("c", 0, 3, &logOutput{w: c})}))
+	defer func() {
+		assert.Nil(t, DeleteLogger("broadcast.a"))
+		assert.Nil(t, DeleteLogger("broadcast.b"))
+		assert.Nil(t, DeleteLogger("broadcast.c"))
+	}()
+	reset := func() {
+		a.Reset()
+		b.Reset()
+		c.Reset()
+	}
+
+	t.Run("DefaultLogger", func(t *testing.T) {
+		assert.Equal(t, GetLogger(ConfigKey), DefaultLogger())
+
+		SetDefaultLogger("broadcast.a")
+		Infow("default", "k", "v")
+		Info("default")
+		assert.Equal(t, "[I] default k=v\n[I] default\n", a.String())
+		assert.Empty(t, b.String())
+		assert.Empty(t, c.String())
+		reset()
+
+		// nothing to log
+		SetDefaultLogger("broadcast.none")
+		assert.Nil(t, DefaultLogger())
+		Info("nothing")
+		Infow("nothing")
+		Panic("nothing")
+		Fatal("nothing")
+		SetDefaultLogger(ConfigKey)
+	})
+
+	t.Run("Broadcast", func(t *testing.T) {
+		l := Broadcast().With("k", "v")
+		l.Info("info")
+		l.Named("pool").WarnContext(WithLogFields(context.Background(), "trace", 1), "warn")
+		for _, buf := range []*bytes.Buffer{a, b, c} {
+			assert.Equal(t, "[I] info k=v\n[W] warn k=v trace=1\n", buf.String())
+		}
+		reset()
+
+		// panic once after all loggers
+		assert.PanicsWithValue(t, "[P] panic k=v", func() {
+			l.Panicw("panic")
+		})
+		for _, buf := range []*bytes.Buffer{a, b, c} {
+			assert.Equal(t, "[P] panic k=v\n", buf.String())
+		}
+		reset()
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		SetDefaultLogger("broadcast.a")
+		defer SetDefaultLogger(ConfigKey)
+
+		wg := sync.WaitGroup{}
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				key := "concurrent." + strconv.Itoa(i)
+				assert.Nil(t, SetLogger(key, newLogger(key, 0, 3)))
+				assert.Nil(t, DeleteLogger(key))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				Infof("%d", i)
+				Broadcast().Debug(i)
+			}
+		}()
+		wg.Wait()
+	})
+}
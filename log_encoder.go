@@ -0,0 +1,240 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogFormat log's format
+type LogFormat uint8
+
+const (
+	// TextFormat like standard lib, e.g. 2022/01/02 15:04:05 main.go:10: [I] message key=value
+	TextFormat LogFormat = iota
+	// JSONFormat one json object per line
+	JSONFormat
+	// LogfmtFormat key=value pairs per line
+	LogfmtFormat
+)
+
+// String for LogFormat Config
+func (f LogFormat) String() string {
+	switch f {
+	case TextFormat:
+		return "text"
+	case JSONFormat:
+		return "json"
+	case LogfmtFormat:
+		return "logfmt"
+	default:
+		return fmt.Sprintf("tao.LogFormat(%d)", f)
+	}
+}
+
+// MarshalText instead of number
+func (f LogFormat) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText to number
+func (f *LogFormat) UnmarshalText(text []byte) error {
+	switch lower := string(bytes.ToLower(text)); lower {
+	case "text", "":
+		*f = TextFormat
+	case "json":
+		*f = JSONFormat
+	case "logfmt":
+		*f = LogfmtFormat
+	default:
+		return fmt.Errorf("log: unrecognized LogFormat: %q", lower)
+	}
+	return nil
+}
+
+// logEntry one line of log
+type logEntry struct {
+	time    time.Time
+	level   LogLevel
+	file    string
+	line    int
+	key     string
+	message string
+	fields  []interface{}
+}
+
+// caller of entry in short or long file
+func (e *logEntry) caller(flag LogFlag) string {
+	if e.file == "" {
+		return ""
+	}
+	file := e.file
+	if flag&log.Llongfile == 0 {
+		file = filepath.Base(file)
+	}
+	return file + ":" + strconv.Itoa(e.line)
+}
+
+// logOutput writer with format of log
+type logOutput struct {
+	mu sync.Mutex
+
+	w      io.Writer
+	format LogFormat
+	// pretty encoder instead of format if not nil
+	pretty *prettyEncoder
+}
+
+// LevelWriter writes line with its level, e.g. syslog maps level to severity
+type LevelWriter interface {
+	io.Writer
+	WriteLevel(level LogLevel, p []byte) (n int, err error)
+}
+
+// entryWriter writes entry with structured fields, e.g. journald
+type entryWriter interface {
+	io.Writer
+	writeEntry(e *logEntry) error
+}
+
+// write entry encoded as line to writer
+func (o *logOutput) write(e *logEntry, line []byte) (err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	switch w := o.w.(type) {
+	case entryWriter:
+		err = w.writeEntry(e)
+	case LevelWriter:
+		_, err = w.WriteLevel(e.level, line)
+	default:
+		_, err = w.Write(line)
+	}
+	return
+}
+
+// encode entry in format
+func encode(e *logEntry, format LogFormat, flag LogFlag) []byte {
+	switch format {
+	case JSONFormat:
+		return encodeJSON(e, flag)
+	case LogfmtFormat:
+		return encodeLogfmt(e, flag)
+	default:
+		return encodeText(e, flag)
+	}
+}
+
+// encodeText in the same header as standard lib
+func encodeText(e *logEntry, flag LogFlag) []byte {
+	buf := make([]byte, 0, 64+len(e.message))
+	now := e.time
+	if flag&log.LUTC != 0 {
+		now = now.UTC()
+	}
+	if flag&log.Ldate != 0 {
+		buf = now.AppendFormat(buf, "2006/01/02 ")
+	}
+	if flag&(log.Ltime|log.Lmicroseconds) != 0 {
+		if flag&log.Lmicroseconds != 0 {
+			buf = now.AppendFormat(buf, "15:04:05.000000 ")
+		} else {
+			buf = now.AppendFormat(buf, "15:04:05 ")
+		}
+	}
+	if flag&(log.Lshortfile|log.Llongfile) != 0 {
+		caller := e.caller(flag)
+		if caller == "" {
+			caller = "???:0"
+		}
+		buf = append(buf, caller...)
+		buf = append(buf, ": "...)
+	}
+	buf = append(buf, levelPrefix[e.level]...)
+	buf = append(buf, appendFields(e.message, e.fields)...)
+	if len(buf) == 0 || buf[len(buf)-1] != '\n' {
+		buf = append(buf, '\n')
+	}
+	return buf
+}
+
+// encodeJSON with time, level, caller, logger, msg & fields
+func encodeJSON(e *logEntry, flag LogFlag) []byte {
+	buf := bytes.NewBuffer(make([]byte, 0, 128+len(e.message)))
+	writePair := func(key string, val interface{}) {
+		if buf.Len() != 1 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(jsonValue(val))
+	}
+
+	buf.WriteByte('{')
+	writePair("time", logTime(e.time, flag))
+	writePair("level", e.level.String())
+	if caller := e.caller(flag); caller != "" {
+		writePair("caller", caller)
+	}
+	if e.key != "" {
+		writePair("logger", e.key)
+	}
+	writePair("msg", strings.TrimSuffix(e.message, "\n"))
+	rangeFields(e.fields, writePair)
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+// jsonValue of field, errors & values can't be marshaled are written as string
+func jsonValue(val interface{}) []byte {
+	if err, ok := val.(error); ok {
+		val = err.Error()
+	}
+	b, err := json.Marshal(val)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprint(val))
+	}
+	return b
+}
+
+// encodeLogfmt with time, level, caller, logger, msg & fields
+func encodeLogfmt(e *logEntry, flag LogFlag) []byte {
+	fields := []interface{}{"time", logTime(e.time, flag), "level", e.level.String()}
+	if caller := e.caller(flag); caller != "" {
+		fields = append(fields, "caller", caller)
+	}
+	if e.key != "" {
+		fields = append(fields, "logger", e.key)
+	}
+	fields = append(fields, "msg", strings.TrimSuffix(e.message, "\n"))
+	return []byte(formatFields(joinFields(fields, e.fields)) + "\n")
+}
+
+// logTime in RFC3339 with nanoseconds
+func logTime(now time.Time, flag LogFlag) string {
+	if flag&log.LUTC != 0 {
+		now = now.UTC()
+	}
+	return now.Format(time.RFC3339Nano)
+}
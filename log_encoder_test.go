@@ -0,0 +1,100 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogEncoder(t *testing.T) {
+	entry := &logEntry{
+		time:    time.Date(2022, 1, 2, 15, 4, 5, 123456789, time.UTC),
+		level:   WARNING,
+		file:    "/root/tao/main.go",
+		line:    10,
+		key:     "redis",
+		message: "slow query\n",
+		fields:  []interface{}{"cost", time.Second, "err", errors.New("i/o timeout"), "db", 0},
+	}
+	flag := LogFlag(log.LstdFlags | log.Lshortfile | log.LUTC)
+
+	t.Run("LogFormatMarshal", func(t *testing.T) {
+		t.Log((LogfmtFormat + 1).String())
+
+		marshal, err := json.Marshal(JSONFormat)
+		assert.Nil(t, err)
+		assert.Equal(t, `"json"`, string(marshal))
+
+		var f LogFormat
+		assert.Nil(t, json.Unmarshal([]byte(`"logfmt"`), &f))
+		assert.Equal(t, LogfmtFormat, f)
+		assert.Nil(t, json.Unmarshal([]byte(`"JSON"`), &f))
+		assert.Equal(t, JSONFormat, f)
+		assert.Nil(t, json.Unmarshal([]byte(`"text"`), &f))
+		assert.Equal(t, TextFormat, f)
+		assert.NotNil(t, json.Unmarshal([]byte(`"xml"`), &f))
+
+		var l LogLevel
+		assert.Nil(t, json.Unmarshal([]byte(`"warn"`), &l))
+		assert.Equal(t, WARNING, l)
+	})
+
+	t.Run("Text", func(t *testing.T) {
+		assert.Equal(t, "2022/01/02 15:04:05 main.go:10: [W] slow query cost=1s err=\"i/o timeout\" db=0\n",
+			string(encode(entry, TextFormat, flag)))
+		assert.Equal(t, "15:04:05.123456 /root/tao/main.go:10: [W] slow query cost=1s err=\"i/o timeout\" db=0\n",
+			string(encode(entry, TextFormat, log.Lmicroseconds|log.Llongfile|log.LUTC)))
+		assert.Equal(t, "???:0: [W] slow query cost=1s err=\"i/o timeout\" db=0\n",
+			string(encode(&logEntry{level: WARNING, message: entry.message, fields: entry.fields}, TextFormat, log.Lshortfile)))
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		assert.Equal(t, `{"time":"2022-01-02T15:04:05.123456789Z","level":"warning","caller":"main.go:10","logger":"redis","msg":"slow query","cost":1000000000,"err":"i/o timeout","db":0}`+"\n",
+			string(encode(entry, JSONFormat, flag)))
+		assert.Equal(t, `{"time":"0001-01-01T00:00:00Z","level":"info","msg":"","!BADKEY":"odd"}`+"\n",
+			string(encode(&logEntry{level: INFO, fields: []interface{}{"odd"}}, JSONFormat, log.LUTC)))
+		// value can't be marshaled
+		assert.True(t, strings.HasPrefix(string(jsonValue(make(chan int))), `"0x`))
+	})
+
+	t.Run("Logfmt", func(t *testing.T) {
+		assert.Equal(t, `time=2022-01-02T15:04:05.123456789Z level=warning caller=main.go:10 logger=redis msg="slow query" cost=1s err="i/o timeout" db=0`+"\n",
+			string(encode(entry, LogfmtFormat, flag)))
+	})
+
+	t.Run("NewLogger", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		l := NewLogger("json", buf).With("unit", "json")
+		l.(*logger).outputs[0].format = JSONFormat
+		l.Infow("hello", "n", 1)
+
+		m := make(map[string]interface{})
+		assert.Nil(t, json.Unmarshal(buf.Bytes(), &m))
+		assert.Equal(t, "info", m["level"])
+		assert.Equal(t, "json", m["logger"])
+		assert.Equal(t, "hello", m["msg"])
+		assert.Equal(t, "json", m["unit"])
+		assert.Equal(t, float64(1), m["n"])
+		assert.True(t, strings.HasPrefix(m["caller"].(string), "log_encoder_test.go:"))
+	})
+}
@@ -0,0 +1,148 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// JournaldConfig of Journald log
+type JournaldConfig struct {
+	// Socket of journald, /run/systemd/journal/socket by default
+	Socket string `json:"socket,omitempty"`
+	// Identifier as SYSLOG_IDENTIFIER, program name by default
+	Identifier string `json:"identifier,omitempty"`
+}
+
+// defaultJournalSocket of systemd
+const defaultJournalSocket = "/run/systemd/journal/socket"
+
+var _ entryWriter = (*journaldWriter)(nil)
+
+// journaldWriter writes entries with fields in native protocol of journald
+// entries larger than datagram limit of socket are failed to write
+type journaldWriter struct {
+	mu sync.Mutex
+
+	identifier string
+	conn       *net.UnixConn
+}
+
+// newJournaldWriter connected to journald socket of c, default socket if c is nil
+func newJournaldWriter(c *JournaldConfig) (*journaldWriter, error) {
+	if c == nil {
+		c = new(JournaldConfig)
+	}
+	socket := c.Socket
+	if socket == "" {
+		socket = defaultJournalSocket
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	w := &journaldWriter{identifier: c.Identifier, conn: conn}
+	if w.identifier == "" {
+		w.identifier = filepath.Base(os.Args[0])
+	}
+	return w, nil
+}
+
+// Write p as message in info level
+func (w *journaldWriter) Write(p []byte) (int, error) {
+	err := w.writeEntry(&logEntry{level: INFO, message: string(p)})
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// writeEntry as fields of journal
+func (w *journaldWriter) writeEntry(e *logEntry) error {
+	buf := new(bytes.Buffer)
+	writeJournalField(buf, "MESSAGE", strings.TrimSuffix(e.message, "\n"))
+	writeJournalField(buf, "PRIORITY", strconv.Itoa(syslogSeverity(e.level)))
+	writeJournalField(buf, "SYSLOG_IDENTIFIER", w.identifier)
+	if e.key != "" {
+		writeJournalField(buf, "LOGGER", e.key)
+	}
+	if e.file != "" {
+		writeJournalField(buf, "CODE_FILE", e.file)
+		writeJournalField(buf, "CODE_LINE", strconv.Itoa(e.line))
+	}
+	rangeFields(e.fields, func(key string, val interface{}) {
+		writeJournalField(buf, journalFieldName(key), fieldString(val))
+	})
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err := w.conn.Write(buf.Bytes())
+	return err
+}
+
+// writeJournalField as KEY=value, or KEY\n<length>value if value is multi-line
+func writeJournalField(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key)
+	if strings.ContainsRune(value, '\n') {
+		buf.WriteByte('\n')
+		_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	} else {
+		buf.WriteByte('=')
+	}
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journalReserved fields written by journaldWriter or known by journald, which fields can't override
+var journalReserved = map[string]struct{}{
+	"MESSAGE": {}, "MESSAGE_ID": {}, "PRIORITY": {}, "LOGGER": {}, "ERRNO": {},
+	"SYSLOG_IDENTIFIER": {}, "SYSLOG_FACILITY": {}, "SYSLOG_PID": {}, "SYSLOG_TIMESTAMP": {}, "SYSLOG_RAW": {},
+}
+
+// journalFieldName of key, which is made of uppercase letters, digits & underscores
+// and starts with a letter, e.g. "user-id" to "USER_ID"
+// names reserved or prefixed by CODE_ & SYSLOG_ are prefixed by F_, e.g. "message" to "F_MESSAGE"
+func journalFieldName(key string) string {
+	name := []byte(strings.ToUpper(key))
+	for i, c := range name {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			name[i] = '_'
+		}
+	}
+	key = strings.TrimLeft(string(name), "_")
+	_, reserved := journalReserved[key]
+	if reserved || key == "" || key[0] < 'A' || strings.HasPrefix(key, "CODE_") || strings.HasPrefix(key, "SYSLOG_") {
+		key = "F_" + key
+	}
+	if len(key) > 64 {
+		key = key[:64]
+	}
+	return key
+}
+
+// Close connection
+func (w *journaldWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.conn.Close()
+}
@@ -0,0 +1,121 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"log"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// parseJournal fields of native protocol
+func parseJournal(t *testing.T, data []byte) map[string]string {
+	fields := make(map[string]string)
+	for len(data) > 0 {
+		i := bytes.IndexAny(data, "=\n")
+		if !assert.True(t, i > 0) {
+			return fields
+		}
+		key := string(data[:i])
+		if data[i] == '=' {
+			end := bytes.IndexByte(data, '\n')
+			fields[key] = string(data[i+1 : end])
+			data = data[end+1:]
+			continue
+		}
+		size := binary.LittleEndian.Uint64(data[i+1 : i+9])
+		fields[key] = string(data[i+9 : i+9+int(size)])
+		assert.Equal(t, byte('\n'), data[i+9+int(size)])
+		data = data[i+10+int(size):]
+	}
+	return fields
+}
+
+func TestJournaldWriter(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	w, err := newJournaldWriter(&JournaldConfig{Socket: socket, Identifier: "app"})
+	assert.Nil(t, err)
+	defer w.Close()
+
+	buf := make([]byte, 4096)
+	read := func() map[string]string {
+		n, err := conn.Read(buf)
+		assert.Nil(t, err)
+		return parseJournal(t, buf[:n])
+	}
+
+	t.Run("Entry", func(t *testing.T) {
+		l := newLogger("redis", LogFlag(log.Lshortfile), 3, &logOutput{w: w})
+		l.Errorw("slow query\n", "cost", "1s", "user-id", 7, "_trusted", true, "9x", "y", "err", errors.New("line1\nline2"))
+
+		fields := read()
+		assert.Equal(t, "slow query", fields["MESSAGE"])
+		assert.Equal(t, "3", fields["PRIORITY"])
+		assert.Equal(t, "app", fields["SYSLOG_IDENTIFIER"])
+		assert.Equal(t, "redis", fields["LOGGER"])
+		assert.Equal(t, "log_journald_test.go", filepath.Base(fields["CODE_FILE"]))
+		assert.NotEmpty(t, fields["CODE_LINE"])
+		assert.Equal(t, "1s", fields["COST"])
+		assert.Equal(t, "7", fields["USER_ID"])
+		assert.Equal(t, "true", fields["TRUSTED"])
+		assert.Equal(t, "y", fields["F_9X"])
+		assert.Equal(t, "line1\nline2", fields["ERR"])
+	})
+
+	t.Run("Reserved", func(t *testing.T) {
+		l := newLogger("redis", LogFlag(log.Lshortfile), 3, &logOutput{w: w})
+		l.Infow("real", "message", "fake", "priority", 0, "syslog_identifier", "other", "code-file", "x.go", "logger", "other")
+
+		fields := read()
+		assert.Equal(t, "real", fields["MESSAGE"])
+		assert.Equal(t, "6", fields["PRIORITY"])
+		assert.Equal(t, "app", fields["SYSLOG_IDENTIFIER"])
+		assert.Equal(t, "redis", fields["LOGGER"])
+		assert.Equal(t, "log_journald_test.go", filepath.Base(fields["CODE_FILE"]))
+		assert.Equal(t, "fake", fields["F_MESSAGE"])
+		assert.Equal(t, "0", fields["F_PRIORITY"])
+		assert.Equal(t, "other", fields["F_SYSLOG_IDENTIFIER"])
+		assert.Equal(t, "x.go", fields["F_CODE_FILE"])
+		assert.Equal(t, "other", fields["F_LOGGER"])
+	})
+
+	t.Run("Write", func(t *testing.T) {
+		n, err := w.Write([]byte("banner\n"))
+		assert.Nil(t, err)
+		assert.Equal(t, 7, n)
+
+		fields := read()
+		assert.Equal(t, "banner", fields["MESSAGE"])
+		assert.Equal(t, "6", fields["PRIORITY"])
+		_, ok := fields["LOGGER"]
+		assert.False(t, ok)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		_, err := newJournaldWriter(&JournaldConfig{Socket: filepath.Join(t.TempDir(), "none.sock")})
+		assert.NotNil(t, err)
+		assert.Equal(t, "F_", journalFieldName("__"))
+	})
+}
@@ -0,0 +1,196 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// Pretty config of console log, which is human-friendly for development
+type Pretty struct {
+	// Color of console log
+	Color ColorMode `json:"color"`
+	// RelativeTime since tao started instead of wall clock
+	RelativeTime bool `json:"relative_time"`
+}
+
+// ColorMode of pretty console log
+type ColorMode uint8
+
+const (
+	// ColorAuto colors if stdout is a TTY & NO_COLOR is not set
+	ColorAuto ColorMode = iota
+	// ColorAlways colors
+	ColorAlways
+	// ColorNever colors
+	ColorNever
+)
+
+// String for ColorMode Config
+func (c ColorMode) String() string {
+	switch c {
+	case ColorAuto:
+		return "auto"
+	case ColorAlways:
+		return "always"
+	case ColorNever:
+		return "never"
+	default:
+		return fmt.Sprintf("tao.ColorMode(%d)", c)
+	}
+}
+
+// MarshalText instead of number
+func (c ColorMode) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText to number
+func (c *ColorMode) UnmarshalText(text []byte) error {
+	switch lower := string(bytes.ToLower(text)); lower {
+	case "auto", "":
+		*c = ColorAuto
+	case "always":
+		*c = ColorAlways
+	case "never":
+		*c = ColorNever
+	default:
+		return fmt.Errorf("log: unrecognized ColorMode: %q", lower)
+	}
+	return nil
+}
+
+// colorEnabled of mode on f
+func colorEnabled(mode ColorMode, f *os.File) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// ansi escape codes
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiFaint  = "\x1b[2m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiCyan   = "\x1b[36m"
+	ansiGray   = "\x1b[90m"
+)
+
+// prettyLevels tag & color of level
+var prettyLevels = map[LogLevel][2]string{
+	DEBUG:   {"DEBUG", ansiGray},
+	INFO:    {"INFO ", ansiGreen},
+	WARNING: {"WARN ", ansiYellow},
+	ERROR:   {"ERROR", ansiRed},
+	PANIC:   {"PANIC", ansiBold + ansiRed},
+	FATAL:   {"FATAL", ansiBold + ansiRed},
+}
+
+// prettyMessageWidth which messages are padded to, so that fields are aligned
+const prettyMessageWidth = 40
+
+// prettyEncoder of console log
+type prettyEncoder struct {
+	color    bool
+	relative bool
+	start    time.Time
+}
+
+// newPrettyEncoder of stdout, relative time starts from now of universe's clock
+func newPrettyEncoder(p *Pretty) *prettyEncoder {
+	return &prettyEncoder{
+		color:    colorEnabled(p.Color, os.Stdout),
+		relative: p.RelativeTime,
+		start:    GetClock().Now(),
+	}
+}
+
+// paint s in color if enabled
+func (p *prettyEncoder) paint(b *strings.Builder, color, s string) {
+	if p.color {
+		b.WriteString(color)
+		b.WriteString(s)
+		b.WriteString(ansiReset)
+	} else {
+		b.WriteString(s)
+	}
+}
+
+// encode entry, e.g. 15:04:05.000 INFO  redis  connected            addr=:6379  main.go:10
+func (p *prettyEncoder) encode(e *logEntry, flag LogFlag) []byte {
+	var b strings.Builder
+	if p.relative {
+		p.paint(&b, ansiFaint, fmt.Sprintf("%+10.3fs", e.time.Sub(p.start).Seconds()))
+	} else {
+		now := e.time
+		if flag&log.LUTC != 0 {
+			now = now.UTC()
+		}
+		p.paint(&b, ansiFaint, now.Format("15:04:05.000"))
+	}
+	b.WriteByte(' ')
+
+	level, ok := prettyLevels[e.level]
+	if !ok {
+		level = [2]string{e.level.String(), ""}
+	}
+	p.paint(&b, level[1], level[0])
+	b.WriteByte(' ')
+
+	if e.key != "" {
+		p.paint(&b, ansiCyan, e.key)
+		b.WriteByte(' ')
+	}
+
+	msg := strings.TrimSuffix(e.message, "\n")
+	b.WriteString(msg)
+	if len(e.fields) != 0 {
+		if n := prettyMessageWidth - len(msg); n > 0 {
+			b.WriteString(strings.Repeat(" ", n))
+		}
+		rangeFields(e.fields, func(key string, val interface{}) {
+			b.WriteByte(' ')
+			p.paint(&b, ansiFaint, key+"=")
+			b.WriteString(quoteValue(val))
+		})
+	}
+
+	if flag&(log.Lshortfile|log.Llongfile) != 0 {
+		caller := e.caller(flag)
+		if caller == "" {
+			caller = "???:0"
+		}
+		b.WriteString("  ")
+		p.paint(&b, ansiFaint, caller)
+	}
+	b.WriteByte('\n')
+	return []byte(b.String())
+}
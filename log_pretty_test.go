@@ -0,0 +1,99 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrettyEncoder(t *testing.T) {
+	start := time.Date(2022, 1, 2, 15, 4, 5, 0, time.UTC)
+	entry := &logEntry{
+		time:    start.Add(1234 * time.Millisecond),
+		level:   WARNING,
+		file:    "/root/tao/main.go",
+		line:    10,
+		key:     "redis",
+		message: "slow query\n",
+		fields:  []interface{}{"cost", time.Second, "sql", "select 1"},
+	}
+
+	t.Run("ColorMode", func(t *testing.T) {
+		p := new(Pretty)
+		assert.Nil(t, json.Unmarshal([]byte(`{"color":"never","relative_time":true}`), p))
+		assert.Equal(t, Pretty{Color: ColorNever, RelativeTime: true}, *p)
+		assert.NotNil(t, json.Unmarshal([]byte(`{"color":"rainbow"}`), p))
+		marshal, err := json.Marshal(ColorAlways)
+		assert.Nil(t, err)
+		assert.Equal(t, `"always"`, string(marshal))
+		t.Log(ColorMode(3))
+
+		f, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+		assert.Nil(t, err)
+		defer f.Close()
+		// not a TTY
+		assert.False(t, colorEnabled(ColorAuto, f))
+		assert.True(t, colorEnabled(ColorAlways, f))
+		assert.False(t, colorEnabled(ColorNever, os.Stdout))
+		t.Setenv("NO_COLOR", "1")
+		assert.False(t, colorEnabled(ColorAuto, os.Stdout))
+	})
+
+	t.Run("Plain", func(t *testing.T) {
+		p := &prettyEncoder{start: start}
+		assert.Equal(t, "15:04:06.234 WARN  redis slow query                               cost=1s sql=\"select 1\"  main.go:10\n",
+			string(p.encode(entry, log.Lshortfile|log.LUTC)))
+
+		p.relative = true
+		assert.Equal(t, "    +1.234s INFO  done\n",
+			string(p.encode(&logEntry{time: entry.time, level: INFO, message: "done"}, 0)))
+	})
+
+	t.Run("Color", func(t *testing.T) {
+		p := &prettyEncoder{color: true, start: start}
+		line := string(p.encode(entry, 0))
+		t.Log(line)
+		assert.Contains(t, line, ansiYellow+"WARN "+ansiReset)
+		assert.Contains(t, line, ansiCyan+"redis"+ansiReset)
+		assert.Contains(t, line, ansiFaint+"cost="+ansiReset+"1s")
+	})
+
+	t.Run("Clock", func(t *testing.T) {
+		// each Now of stepClock steps a minute
+		SetClock(&stepClock{now: start})
+		defer SetClock(nil)
+		p := newPrettyEncoder(&Pretty{RelativeTime: true, Color: ColorNever})
+		assert.Equal(t, "   +60.000s INFO  done\n",
+			string(p.encode(&logEntry{time: GetClock().Now(), level: INFO, message: "done"}, 0)))
+	})
+
+	t.Run("Logger", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		l := newLogger("", 0, 2, &logOutput{w: buf, pretty: &prettyEncoder{relative: true, start: time.Now()}}, &logOutput{w: buf})
+		l.Errorw("failed", "n", 1)
+		lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+		assert.Len(t, lines, 2)
+		assert.Contains(t, string(lines[0]), "s ERROR failed")
+		assert.Equal(t, "[E] failed n=1", string(lines[1]))
+	})
+}
@@ -0,0 +1,364 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Rotate config of File log
+type Rotate struct {
+	// MaxSize in megabytes of log file before rotated, 0 for no limit
+	MaxSize int `json:"max_size"`
+	// Interval to rotate log file, hourly or daily
+	Interval RotateInterval `json:"interval,omitempty"`
+	// MaxBackups of rotated files to retain, 0 to retain all
+	MaxBackups int `json:"max_backups"`
+	// MaxAge in days of rotated files to retain, 0 to retain all
+	MaxAge int `json:"max_age"`
+	// Compress rotated files by gzip
+	Compress bool `json:"compress"`
+	// ReopenOnSIGHUP for external rotation like logrotate
+	ReopenOnSIGHUP bool `json:"reopen_on_sighup"`
+}
+
+// RotateInterval of log file
+type RotateInterval uint8
+
+const (
+	// Never rotate by time
+	Never RotateInterval = iota
+	// Hourly rotate
+	Hourly
+	// Daily rotate
+	Daily
+)
+
+// String for RotateInterval Config
+func (r RotateInterval) String() string {
+	switch r {
+	case Never:
+		return ""
+	case Hourly:
+		return "hourly"
+	case Daily:
+		return "daily"
+	default:
+		return fmt.Sprintf("tao.RotateInterval(%d)", r)
+	}
+}
+
+// MarshalText instead of number
+func (r RotateInterval) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText to number
+func (r *RotateInterval) UnmarshalText(text []byte) error {
+	switch lower := string(bytes.ToLower(text)); lower {
+	case "", "never":
+		*r = Never
+	case "hourly":
+		*r = Hourly
+	case "daily":
+		*r = Daily
+	default:
+		return fmt.Errorf("log: unrecognized RotateInterval: %q", lower)
+	}
+	return nil
+}
+
+// backupTimeFormat of rotated file name, e.g. test-20220102T150405.000.log
+const backupTimeFormat = "20060102T150405.000"
+
+var _ io.WriteCloser = (*rotateWriter)(nil)
+
+// rotateWriter file writer rotated by size & time
+type rotateWriter struct {
+	mu sync.Mutex
+
+	path   string
+	rotate Rotate
+
+	file   *os.File
+	size   int64
+	period time.Time
+
+	// cleanup of backups in background
+	clean  sync.WaitGroup
+	sighup chan os.Signal
+}
+
+// newRotateWriter of path, rotate can be nil to never rotate
+func newRotateWriter(path string, rotate *Rotate) (*rotateWriter, error) {
+	w := &rotateWriter{path: path}
+	if rotate != nil {
+		w.rotate = *rotate
+	}
+
+	err := w.open()
+	if err != nil {
+		return nil, err
+	}
+
+	if w.rotate.ReopenOnSIGHUP {
+		w.sighup = make(chan os.Signal, 1)
+		signal.Notify(w.sighup, syscall.SIGHUP)
+		go func(sighup chan os.Signal) {
+			for range sighup {
+				_ = w.Reopen()
+			}
+		}(w.sighup)
+	}
+	return w, nil
+}
+
+// openFile of log, replaced in tests
+var openFile = os.OpenFile
+
+// open log file with lock held, the file opened before is closed after the new one opened
+func (w *rotateWriter) open() error {
+	file, err := openFile(w.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	if w.file != nil {
+		_ = w.file.Close()
+	}
+	w.file = file
+	w.size = info.Size()
+	// file written before is rotated in its own period
+	w.period = w.periodOf(info.ModTime())
+	if w.size == 0 {
+		w.period = w.periodOf(GetClock().Now())
+	}
+	return nil
+}
+
+// periodOf time by interval
+func (w *rotateWriter) periodOf(now time.Time) time.Time {
+	switch w.rotate.Interval {
+	case Hourly:
+		return time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), 0, 0, 0, now.Location())
+	case Daily:
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	default:
+		return time.Time{}
+	}
+}
+
+// Write p to log file, which is rotated before if needed
+func (w *rotateWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	now := GetClock().Now()
+	maxSize := int64(w.rotate.MaxSize) * 1024 * 1024
+	var rotateErr error
+	if (maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > maxSize) ||
+		(w.rotate.Interval != Never && !w.periodOf(now).Equal(w.period)) {
+		// keep writing to the current file if failed, rotate again next time
+		rotateErr = w.rotateAt(now)
+	}
+
+	n, err = w.file.Write(p)
+	w.size += int64(n)
+	if err == nil && rotateErr != nil {
+		err = NewErrorWrapped("log: fail to rotate file", rotateErr)
+	}
+	return
+}
+
+// rotateAt now with lock held, the current file is still written if failed
+func (w *rotateWriter) rotateAt(now time.Time) error {
+	ext := filepath.Ext(w.path)
+	prefix := strings.TrimSuffix(w.path, ext) + "-"
+	backup := prefix + now.Format(backupTimeFormat) + ext
+	for i := 1; ; i++ {
+		if _, err := os.Stat(backup); os.IsNotExist(err) {
+			break
+		}
+		backup = fmt.Sprintf("%s%s.%d%s", prefix, now.Format(backupTimeFormat), i, ext)
+	}
+	err := os.Rename(w.path, backup)
+	if err != nil {
+		return err
+	}
+
+	err = w.open()
+	if err != nil {
+		// move back to path of the current file
+		_ = os.Rename(backup, w.path)
+		return err
+	}
+	w.period = w.periodOf(now)
+
+	w.clean.Add(1)
+	go func() {
+		defer w.clean.Done()
+		w.cleanup(now)
+	}()
+	return nil
+}
+
+// cleanup backups by compress, max backups & max age
+func (w *rotateWriter) cleanup(now time.Time) {
+	// one cleanup at a time
+	cleanMu.Lock()
+	defer cleanMu.Unlock()
+
+	ext := filepath.Ext(w.path)
+	prefix := filepath.Base(strings.TrimSuffix(w.path, ext)) + "-"
+	dir := filepath.Dir(w.path)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	type backup struct {
+		path string
+		time time.Time
+	}
+	backups := make([]backup, 0)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ext)
+		stamp = strings.TrimPrefix(stamp, prefix)
+		if len(stamp) < len(backupTimeFormat) {
+			continue
+		}
+		bt, err := time.ParseInLocation(backupTimeFormat, stamp[:len(backupTimeFormat)], now.Location())
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{path: filepath.Join(dir, name), time: bt})
+	}
+
+	// newest first
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].time.After(backups[j].time)
+	})
+	for i, b := range backups {
+		if (w.rotate.MaxBackups > 0 && i >= w.rotate.MaxBackups) ||
+			(w.rotate.MaxAge > 0 && now.Sub(b.time) > time.Duration(w.rotate.MaxAge)*24*time.Hour) {
+			_ = os.Remove(b.path)
+			continue
+		}
+		if w.rotate.Compress && !strings.HasSuffix(b.path, ".gz") {
+			_ = compressFile(b.path)
+		}
+	}
+}
+
+// cleanMu to cleanup backups one by one
+var cleanMu sync.Mutex
+
+// compressFile to path.gz & remove path
+func compressFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(path + ".gz")
+		}
+	}()
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	_ = src.Close()
+	return os.Remove(path)
+}
+
+// Reopen log file, for external rotation which has moved it
+func (w *rotateWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	return w.open()
+}
+
+// Sync log file to disk
+func (w *rotateWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	return w.file.Sync()
+}
+
+// Close log file
+func (w *rotateWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.sighup != nil {
+		signal.Stop(w.sighup)
+		close(w.sighup)
+		w.sighup = nil
+	}
+	w.clean.Wait()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
@@ -0,0 +1,248 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotateWriter(t *testing.T) {
+	backups := func(dir string) []string {
+		entries, err := os.ReadDir(dir)
+		assert.Nil(t, err)
+		names := make([]string, 0)
+		for _, entry := range entries {
+			if entry.Name() != "app.log" {
+				names = append(names, entry.Name())
+			}
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	t.Run("Config", func(t *testing.T) {
+		r := new(Rotate)
+		assert.Nil(t, json.Unmarshal([]byte(`{"max_size":10,"interval":"daily","max_backups":3,"compress":true}`), r))
+		assert.Equal(t, Rotate{MaxSize: 10, Interval: Daily, MaxBackups: 3, Compress: true}, *r)
+		assert.NotNil(t, json.Unmarshal([]byte(`{"interval":"weekly"}`), r))
+
+		marshal, err := json.Marshal(Hourly)
+		assert.Nil(t, err)
+		assert.Equal(t, `"hourly"`, string(marshal))
+		t.Log(RotateInterval(3))
+	})
+
+	t.Run("Size", func(t *testing.T) {
+		dir := t.TempDir()
+		w, err := newRotateWriter(filepath.Join(dir, "app.log"), &Rotate{MaxSize: 1, MaxBackups: 2, Compress: true})
+		assert.Nil(t, err)
+
+		chunk := bytes.Repeat([]byte("a"), 600*1024)
+		for i := 0; i < 5; i++ {
+			_, err = w.Write(chunk)
+			assert.Nil(t, err)
+		}
+		assert.Nil(t, w.Close())
+
+		names := backups(dir)
+		assert.Len(t, names, 2)
+		for _, name := range names {
+			assert.True(t, strings.HasPrefix(name, "app-"))
+			assert.True(t, strings.HasSuffix(name, ".log.gz"))
+		}
+		info, err := os.Stat(filepath.Join(dir, "app.log"))
+		assert.Nil(t, err)
+		assert.Equal(t, int64(len(chunk)), info.Size())
+
+		_, err = w.Write(chunk)
+		assert.Equal(t, os.ErrClosed, err)
+	})
+
+	t.Run("Interval", func(t *testing.T) {
+		dir := t.TempDir()
+		w, err := newRotateWriter(filepath.Join(dir, "app.log"), &Rotate{Interval: Hourly})
+		assert.Nil(t, err)
+
+		_, err = w.Write([]byte("first\n"))
+		assert.Nil(t, err)
+		assert.Len(t, backups(dir), 0)
+
+		// last hour
+		w.mu.Lock()
+		w.period = w.period.Add(-time.Hour)
+		w.mu.Unlock()
+		_, err = w.Write([]byte("second\n"))
+		assert.Nil(t, err)
+		assert.Nil(t, w.Close())
+
+		names := backups(dir)
+		assert.Len(t, names, 1)
+		data, err := os.ReadFile(filepath.Join(dir, names[0]))
+		assert.Nil(t, err)
+		assert.Equal(t, "first\n", string(data))
+		data, err = os.ReadFile(filepath.Join(dir, "app.log"))
+		assert.Nil(t, err)
+		assert.Equal(t, "second\n", string(data))
+	})
+
+	t.Run("Clock", func(t *testing.T) {
+		SetClock(&stepClock{now: time.Date(2022, 1, 1, 0, 58, 0, 0, time.Local)})
+		defer SetClock(nil)
+
+		dir := t.TempDir()
+		w, err := newRotateWriter(filepath.Join(dir, "app.log"), &Rotate{Interval: Hourly})
+		assert.Nil(t, err)
+		// 00:59
+		assert.Equal(t, time.Date(2022, 1, 1, 0, 0, 0, 0, time.Local), w.period)
+		// 01:00
+		_, err = w.Write([]byte("first\n"))
+		assert.Nil(t, err)
+		assert.Nil(t, w.Close())
+		assert.Equal(t, []string{"app-20220101T010000.000.log"}, backups(dir))
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+		w, err := newRotateWriter(path, &Rotate{MaxSize: 1})
+		assert.Nil(t, err)
+		_, err = w.Write(bytes.Repeat([]byte("a"), 1024*1024))
+		assert.Nil(t, err)
+
+		// reopen fails after renamed
+		defer func() {
+			openFile = os.OpenFile
+		}()
+		openFile = func(string, int, os.FileMode) (*os.File, error) {
+			return nil, os.ErrPermission
+		}
+		n, err := w.Write([]byte("b\n"))
+		assert.Equal(t, 2, n)
+		assert.ErrorIs(t, err, os.ErrPermission)
+		assert.Len(t, backups(dir), 0)
+		n, err = w.Write([]byte("c\n"))
+		assert.Equal(t, 2, n)
+		assert.NotNil(t, err)
+		assert.Equal(t, os.ErrPermission, w.Reopen())
+
+		// recovered
+		openFile = os.OpenFile
+		_, err = w.Write([]byte("d\n"))
+		assert.Nil(t, err)
+		assert.Nil(t, w.Close())
+
+		names := backups(dir)
+		assert.Len(t, names, 1)
+		data, err := os.ReadFile(filepath.Join(dir, names[0]))
+		assert.Nil(t, err)
+		assert.Equal(t, strings.Repeat("a", 1024*1024)+"b\nc\n", string(data))
+		data, err = os.ReadFile(path)
+		assert.Nil(t, err)
+		assert.Equal(t, "d\n", string(data))
+	})
+
+	t.Run("MaxAge", func(t *testing.T) {
+		dir := t.TempDir()
+		old := filepath.Join(dir, "app-"+time.Now().AddDate(0, 0, -3).Format(backupTimeFormat)+".log")
+		assert.Nil(t, os.WriteFile(old, []byte("old\n"), 0666))
+
+		w, err := newRotateWriter(filepath.Join(dir, "app.log"), &Rotate{MaxSize: 1, MaxAge: 1})
+		assert.Nil(t, err)
+		w.mu.Lock()
+		assert.Nil(t, w.rotateAt(time.Now()))
+		w.mu.Unlock()
+		assert.Nil(t, w.Close())
+
+		names := backups(dir)
+		assert.Len(t, names, 1)
+		assert.NotEqual(t, filepath.Base(old), names[0])
+	})
+
+	t.Run("Reopen", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+		w, err := newRotateWriter(path, &Rotate{ReopenOnSIGHUP: true})
+		assert.Nil(t, err)
+
+		_, err = w.Write([]byte("before\n"))
+		assert.Nil(t, err)
+		// moved by logrotate
+		assert.Nil(t, os.Rename(path, path+".1"))
+		assert.Nil(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+		assert.Eventually(t, func() bool {
+			_, err := os.Stat(path)
+			return err == nil
+		}, time.Second, 10*time.Millisecond)
+
+		_, err = w.Write([]byte("after\n"))
+		assert.Nil(t, err)
+		assert.Nil(t, w.Close())
+		assert.Nil(t, w.Close())
+		assert.Equal(t, os.ErrClosed, w.Reopen())
+
+		data, err := os.ReadFile(path)
+		assert.Nil(t, err)
+		assert.Equal(t, "after\n", string(data))
+		data, err = os.ReadFile(path + ".1")
+		assert.Nil(t, err)
+		assert.Equal(t, "before\n", string(data))
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		dir := t.TempDir()
+		w, err := newRotateWriter(filepath.Join(dir, "app.log"), &Rotate{MaxSize: 1})
+		assert.Nil(t, err)
+
+		line := strings.Repeat("c", 1023)
+		l1 := newLogger("a", 0, 2, &logOutput{w: w})
+		l2 := newLogger("b", 0, 2, &logOutput{w: w})
+		wg := sync.WaitGroup{}
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(l StructuredLogger) {
+				defer wg.Done()
+				for j := 0; j < 200; j++ {
+					l.Info(line)
+				}
+			}([]StructuredLogger{l1, l2}[i%2])
+		}
+		wg.Wait()
+		assert.Nil(t, w.Close())
+
+		var total int
+		for _, name := range append(backups(dir), "app.log") {
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			assert.Nil(t, err)
+			assert.LessOrEqual(t, len(data), 1024*1024)
+			// no line is torn apart
+			for _, l := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+				assert.Equal(t, len(levelPrefix[INFO])+len(line), len(l))
+				total++
+			}
+		}
+		assert.Equal(t, 8*200, total)
+	})
+}
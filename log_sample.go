@@ -0,0 +1,146 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Sampling config of log, panic & fatal lines are never suppressed
+type Sampling struct {
+	// Interval in milliseconds of sampling & rate limit, 1000 by default
+	Interval int `json:"interval"`
+	// First lines of the same level & message template logged per interval, 0 to disable sampling
+	First int `json:"first"`
+	// Thereafter every Mth line of the same level & message template logged, 0 to suppress all
+	Thereafter int `json:"thereafter"`
+	// RateLimit of lines per interval by logger key, 0 for no limit
+	RateLimit int `json:"rate_limit"`
+}
+
+// sampleKey of lines sampled together
+type sampleKey struct {
+	level    LogLevel
+	template string
+}
+
+// sampler of lines, counters are reset & suppressed lines are reported every interval
+type sampler struct {
+	config   Sampling
+	interval time.Duration
+
+	mu         sync.Mutex
+	counts     map[sampleKey]int
+	allowed    map[string]int
+	suppressed map[string]uint64
+
+	report func(suppressed map[string]uint64, interval time.Duration)
+	stop   chan struct{}
+}
+
+// newSampler reports suppressed lines by logger key every interval
+func newSampler(config Sampling, report func(suppressed map[string]uint64, interval time.Duration)) *sampler {
+	if config.Interval <= 0 {
+		config.Interval = 1000
+	}
+	s := &sampler{
+		config:     config,
+		interval:   time.Duration(config.Interval) * time.Millisecond,
+		counts:     make(map[sampleKey]int),
+		allowed:    make(map[string]int),
+		suppressed: make(map[string]uint64),
+		report:     report,
+		stop:       make(chan struct{}),
+	}
+
+	go func() {
+		ticker := GetClock().NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C():
+				s.tick()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+	return s
+}
+
+// allow line of logger key in level & template
+func (s *sampler) allow(key string, level LogLevel, template string) bool {
+	if level >= PANIC {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.config.RateLimit > 0 && s.allowed[key] >= s.config.RateLimit {
+		s.suppressed[key]++
+		return false
+	}
+	if s.config.First > 0 {
+		k := sampleKey{level: level, template: template}
+		s.counts[k]++
+		if n := s.counts[k] - s.config.First; n > 0 && (s.config.Thereafter <= 0 || n%s.config.Thereafter != 0) {
+			s.suppressed[key]++
+			return false
+		}
+	}
+	s.allowed[key]++
+	return true
+}
+
+// tick to reset counters & report suppressed lines
+func (s *sampler) tick() {
+	s.mu.Lock()
+	suppressed := s.suppressed
+	s.counts = make(map[sampleKey]int)
+	s.allowed = make(map[string]int)
+	s.suppressed = make(map[string]uint64)
+	s.mu.Unlock()
+
+	if len(suppressed) != 0 && s.report != nil {
+		s.report(suppressed, s.interval)
+	}
+}
+
+// close sampler
+func (s *sampler) close() {
+	close(s.stop)
+}
+
+// sample lines of logger by config, the summary of suppressed lines is logged in warning level
+func (l *logger) sample(config Sampling) {
+	l.sampler = newSampler(config, func(suppressed map[string]uint64, interval time.Duration) {
+		keys := make([]string, 0, len(suppressed))
+		for k := range suppressed {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			name := k
+			if name == "" {
+				name = ConfigKey
+			}
+			l.write(WARNING, "log: lines suppressed by sampling",
+				[]interface{}{"key", name, "suppressed", suppressed[k], "interval", interval.String()})
+		}
+	})
+}
@@ -0,0 +1,100 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampler(t *testing.T) {
+	t.Run("Config", func(t *testing.T) {
+		s := new(Sampling)
+		assert.Nil(t, json.Unmarshal([]byte(`{"interval":500,"first":10,"thereafter":100,"rate_limit":1000}`), s))
+		assert.Equal(t, Sampling{Interval: 500, First: 10, Thereafter: 100, RateLimit: 1000}, *s)
+	})
+
+	t.Run("Sampling", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		l := newLogger("redis", 0, 2, &logOutput{w: buf})
+		l.sample(Sampling{Interval: int(time.Hour / time.Millisecond), First: 2, Thereafter: 3})
+		defer l.sampler.close()
+
+		for i := 1; i <= 10; i++ {
+			l.Errorf("retry %d failed", i)
+		}
+		// different level
+		l.Warnf("retry %d failed", 1)
+		assert.Equal(t, "[E] retry 1 failed\n[E] retry 2 failed\n[E] retry 5 failed\n[E] retry 8 failed\n[W] retry 1 failed\n", buf.String())
+		buf.Reset()
+
+		assert.PanicsWithValue(t, "[P] panic", func() {
+			for i := 0; i < 3; i++ {
+				l.Panicf("panic")
+			}
+		})
+
+		l.sampler.tick()
+		assert.Equal(t, "[P] panic\n[W] log: lines suppressed by sampling key=redis suppressed=6 interval=1h0m0s\n", buf.String())
+		buf.Reset()
+
+		// reset after interval
+		l.Errorf("retry %d failed", 11)
+		assert.Equal(t, "[E] retry 11 failed\n", buf.String())
+		buf.Reset()
+
+		// nothing suppressed
+		l.sampler.tick()
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("RateLimit", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		l := newLogger("", 0, 2, &logOutput{w: buf})
+		l.sample(Sampling{Interval: int(time.Hour / time.Millisecond), RateLimit: 2})
+		defer l.sampler.close()
+
+		pool := l.Named("pool")
+		for i := 0; i < 3; i++ {
+			l.Info("root", i)
+			pool.Infow("pool", "i", i)
+		}
+		assert.Equal(t, "[I] root 0\n[I] pool i=0\n[I] root 1\n[I] pool i=1\n", buf.String())
+		buf.Reset()
+
+		l.sampler.tick()
+		assert.Equal(t, "[W] log: lines suppressed by sampling key=tao suppressed=1 interval=1h0m0s\n"+
+			"[W] log: lines suppressed by sampling key=pool suppressed=1 interval=1h0m0s\n", buf.String())
+	})
+
+	t.Run("Ticker", func(t *testing.T) {
+		buf := &gateWriter{release: make(chan struct{})}
+		close(buf.release)
+		l := newLogger("ticker", 0, 2, &logOutput{w: buf})
+		l.sample(Sampling{Interval: 10, RateLimit: 1})
+		defer l.sampler.close()
+
+		l.Info("1")
+		l.Info("2")
+		assert.Eventually(t, func() bool {
+			return strings.Contains(buf.String(), "suppressed=1")
+		}, time.Second, 10*time.Millisecond)
+	})
+}
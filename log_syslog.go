@@ -0,0 +1,212 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SyslogConfig of Syslog log
+type SyslogConfig struct {
+	// Network of syslog server, udp, tcp, unix or unixgram, local syslog if empty
+	Network string `json:"network,omitempty"`
+	// Address of syslog server, e.g. 127.0.0.1:514
+	Address string `json:"address,omitempty"`
+	// Facility of syslog, e.g. user, daemon, local0, user by default
+	Facility string `json:"facility,omitempty"`
+	// Tag as APP-NAME of syslog, program name by default
+	Tag string `json:"tag,omitempty"`
+}
+
+// syslogFacilities by name
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// syslogSeverity of level
+func syslogSeverity(level LogLevel) int {
+	switch level {
+	case DEBUG:
+		return 7 // debug
+	case INFO:
+		return 6 // informational
+	case WARNING:
+		return 4 // warning
+	case ERROR:
+		return 3 // error
+	case PANIC:
+		return 2 // critical
+	default:
+		return 1 // alert
+	}
+}
+
+// localSyslogs of unix sockets
+var localSyslogs = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+var _ LevelWriter = (*syslogWriter)(nil)
+var _ entryWriter = (*syslogWriter)(nil)
+
+// syslogWriter writes lines in RFC 5424, framed by octet counting in stream network
+type syslogWriter struct {
+	mu sync.Mutex
+
+	network  string
+	address  string
+	facility int
+	hostname string
+	tag      string
+	pid      int
+
+	conn net.Conn
+}
+
+// newSyslogWriter connected to syslog server of c, local syslog if c is nil
+func newSyslogWriter(c *SyslogConfig) (*syslogWriter, error) {
+	if c == nil {
+		c = new(SyslogConfig)
+	}
+
+	w := &syslogWriter{
+		network:  c.Network,
+		address:  c.Address,
+		facility: syslogFacilities["user"],
+		tag:      c.Tag,
+		pid:      os.Getpid(),
+	}
+	if c.Facility != "" {
+		facility, ok := syslogFacilities[c.Facility]
+		if !ok {
+			return nil, NewError(ParamInvalid, "log: unrecognized syslog facility %q", c.Facility)
+		}
+		w.facility = facility
+	}
+	if w.tag == "" {
+		w.tag = filepath.Base(os.Args[0])
+	}
+	w.hostname, _ = os.Hostname()
+	if w.hostname == "" {
+		w.hostname = "-"
+	}
+
+	err := w.connect()
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// connect to syslog server with lock held
+func (w *syslogWriter) connect() (err error) {
+	if w.conn != nil {
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+
+	if w.network != "" {
+		w.conn, err = net.DialTimeout(w.network, w.address, 5*time.Second)
+		return err
+	}
+
+	// local syslog
+	for _, network := range []string{"unixgram", "unix"} {
+		for _, address := range localSyslogs {
+			conn, err := net.DialTimeout(network, address, 5*time.Second)
+			if err == nil {
+				w.network, w.address, w.conn = network, address, conn
+				return nil
+			}
+		}
+	}
+	return NewError(ParamInvalid, "log: local syslog not found in %v", localSyslogs)
+}
+
+// Write p in info level
+func (w *syslogWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(INFO, p)
+}
+
+// WriteLevel p in severity of level, reconnect once if failed
+func (w *syslogWriter) WriteLevel(level LogLevel, p []byte) (int, error) {
+	return w.write(level, GetClock().Now(), p)
+}
+
+// writeEntry of level, message & fields, whose time is in header instead of body
+func (w *syslogWriter) writeEntry(e *logEntry) error {
+	_, err := w.write(e.level, e.time, []byte(levelPrefix[e.level]+appendFields(e.message, e.fields)))
+	return err
+}
+
+// write p at now, reconnect once if failed
+func (w *syslogWriter) write(level LogLevel, now time.Time, p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	msg := w.format(level, now, p)
+	var err error
+	for i := 0; i < 2; i++ {
+		if w.conn == nil {
+			if err = w.connect(); err != nil {
+				continue
+			}
+		}
+		if _, err = w.conn.Write(msg); err == nil {
+			return len(p), nil
+		}
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+	return 0, err
+}
+
+// format p in RFC 5424, e.g. <14>1 2022-01-02T15:04:05.000000+08:00 host app 123 - - message
+func (w *syslogWriter) format(level LogLevel, now time.Time, p []byte) []byte {
+	p = bytes.TrimRight(p, "\n")
+	header := fmt.Sprintf("<%d>1 %s %s %s %d - - ",
+		w.facility*8+syslogSeverity(level), now.Format("2006-01-02T15:04:05.000000Z07:00"),
+		w.hostname, w.tag, w.pid)
+
+	msg := make([]byte, 0, len(header)+len(p)+8)
+	if w.network == "tcp" || w.network == "tcp4" || w.network == "tcp6" || w.network == "unix" {
+		// octet counting of RFC 6587
+		msg = strconv.AppendInt(msg, int64(len(header)+len(p)), 10)
+		msg = append(msg, ' ')
+	}
+	msg = append(msg, header...)
+	return append(msg, p...)
+}
+
+// Close connection
+func (w *syslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
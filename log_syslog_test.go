@@ -0,0 +1,156 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyslogWriter(t *testing.T) {
+	hostname, _ := os.Hostname()
+	header := regexp.MustCompile(`^<(\d+)>1 \d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{6}(Z|[+-]\d{2}:\d{2}) (\S+) app (\d+) - - `)
+	parse := func(t *testing.T, msg string) (pri int, body string) {
+		m := header.FindStringSubmatch(msg)
+		if !assert.NotNil(t, m, msg) {
+			return
+		}
+		assert.Equal(t, hostname, m[3])
+		assert.Equal(t, strconv.Itoa(os.Getpid()), m[4])
+		pri, _ = strconv.Atoi(m[1])
+		return pri, msg[len(m[0]):]
+	}
+
+	t.Run("LogType", func(t *testing.T) {
+		assert.Equal(t, "console|syslog|journald", (Console | Syslog | Journald).String())
+		t.Log(LogType(16).String())
+
+		var l LogType
+		assert.Nil(t, json.Unmarshal([]byte(`"journald|file"`), &l))
+		assert.Equal(t, File|Journald, l)
+		assert.NotNil(t, json.Unmarshal([]byte(`"syslog|kafka"`), &l))
+	})
+
+	t.Run("UDP", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		assert.Nil(t, err)
+		defer conn.Close()
+
+		w, err := newSyslogWriter(&SyslogConfig{Network: "udp", Address: conn.LocalAddr().String(), Facility: "local0", Tag: "app"})
+		assert.Nil(t, err)
+		defer w.Close()
+
+		// time & caller are not duplicated in body
+		l := newLogger("syslog", LogFlag(log.LstdFlags|log.Lshortfile), 2, &logOutput{w: w})
+		l.Warnw("disk full", "used", "99%")
+		_, err = w.Write([]byte("banner\n"))
+		assert.Nil(t, err)
+
+		buf := make([]byte, 1024)
+		for _, expected := range []struct {
+			pri  int
+			body string
+		}{
+			{16*8 + 4, "[W] disk full used=99%"},
+			{16*8 + 6, "banner"},
+		} {
+			n, _, err := conn.ReadFrom(buf)
+			assert.Nil(t, err)
+			pri, body := parse(t, string(buf[:n]))
+			assert.Equal(t, expected.pri, pri)
+			assert.Equal(t, expected.body, body)
+		}
+	})
+
+	t.Run("TCP", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.Nil(t, err)
+		defer ln.Close()
+
+		w, err := newSyslogWriter(&SyslogConfig{Network: "tcp", Address: ln.Addr().String(), Tag: "app"})
+		assert.Nil(t, err)
+		defer w.Close()
+
+		conn, err := ln.Accept()
+		assert.Nil(t, err)
+		defer conn.Close()
+
+		l := newLogger("syslog", 0, 2, &logOutput{w: w})
+		l.Debug("debug")
+		l.Errorf("error %d", 1)
+
+		r := bufio.NewReader(conn)
+		for _, expected := range []struct {
+			pri  int
+			body string
+		}{
+			{1*8 + 7, "[D] debug"},
+			{1*8 + 3, "[E] error 1"},
+		} {
+			// octet counting
+			size, err := r.ReadString(' ')
+			assert.Nil(t, err)
+			n, err := strconv.Atoi(strings.TrimSpace(size))
+			assert.Nil(t, err)
+			msg := make([]byte, n)
+			_, err = io.ReadFull(r, msg)
+			assert.Nil(t, err)
+
+			pri, body := parse(t, string(msg))
+			assert.Equal(t, expected.pri, pri)
+			assert.Equal(t, expected.body, body)
+		}
+	})
+
+	t.Run("Unixgram", func(t *testing.T) {
+		addr := filepath.Join(t.TempDir(), "syslog.sock")
+		conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+		assert.Nil(t, err)
+		defer conn.Close()
+
+		w, err := newSyslogWriter(&SyslogConfig{Network: "unixgram", Address: addr, Tag: "app"})
+		assert.Nil(t, err)
+		defer w.Close()
+
+		assert.Panics(t, func() {
+			newLogger("syslog", 0, 2, &logOutput{w: w}).Panic("panic")
+		})
+		buf := make([]byte, 1024)
+		n, err := conn.Read(buf)
+		assert.Nil(t, err)
+		pri, body := parse(t, string(buf[:n]))
+		assert.Equal(t, 1*8+2, pri)
+		assert.Equal(t, "[P] panic", body)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		_, err := newSyslogWriter(&SyslogConfig{Network: "udp", Address: "127.0.0.1:514", Facility: "unknown"})
+		assert.NotNil(t, err)
+		_, err = newSyslogWriter(&SyslogConfig{Network: "unixgram", Address: filepath.Join(t.TempDir(), "none.sock")})
+		assert.NotNil(t, err)
+		assert.Equal(t, 1, syslogSeverity(FATAL))
+	})
+}
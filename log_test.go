@@ -15,10 +15,14 @@
 package tao
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"github.com/stretchr/testify/assert"
+	"io"
 	"log"
 	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -193,7 +197,7 @@ func TestLogger(t *testing.T) {
 		assert.NotNil(t, writer)
 
 		assert.Nil(t, DeleteWriter(ConfigKey))
-		assert.Nil(t, globalLogger.writers[ConfigKey])
+		assert.Nil(t, GetWriter(ConfigKey))
 		assert.NotNil(t, DeleteWriter(ConfigKey))
 
 		assert.Nil(t, SetWriter(ConfigKey, writer))
@@ -212,3 +216,175 @@ func TestLogger(t *testing.T) {
 		assert.NotNil(t, SetLogger(ConfigKey, logger))
 	})
 }
+
+// plainLogger implements Logger only
+type plainLogger struct {
+	*logger
+}
+
+func TestStructuredLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := newLogger("", 0, 2, &logOutput{w: buf})
+
+	t.Run("Fields", func(t *testing.T) {
+		assert.Equal(t, `a=1 b="x y" c="" err=EOF !BADKEY=2 !BADKEY=d`, formatFields([]interface{}{"a", 1, "b", "x y", "c", "", "err", io.EOF, 2, "d"}))
+		assert.Equal(t, "msg\n", appendFields("msg\n", nil))
+		assert.Equal(t, "msg a=1", appendFields("msg\n", []interface{}{"a", 1}))
+	})
+
+	t.Run("With", func(t *testing.T) {
+		sl := l.With("unit", "redis")
+		sl.Infow("connected", "addr", ":6379")
+		sl.Info("plain")
+		sl.With("db", 0).Warnf("slow %s", "query")
+		l.Errorw("no fields")
+		assert.Equal(t, `[I] connected unit=redis addr=:6379
+[I] plain unit=redis
+[W] slow query unit=redis db=0
+[E] no fields
+`, buf.String())
+		buf.Reset()
+	})
+
+	t.Run("Context", func(t *testing.T) {
+		ctx := WithLogFields(context.Background(), "trace", "abc")
+		ctx = WithLogFields(ctx, "span", 1)
+		assert.Equal(t, []interface{}{"trace", "abc", "span", 1}, LogFields(ctx))
+		assert.Nil(t, LogFields(nil))
+		assert.Equal(t, []interface{}{"a", 1}, LogFields(WithLogFields(nil, "a", 1)))
+
+		l.DebugContext(ctx, "debug", "k", "v")
+		l.InfoContext(ctx, "info")
+		l.WarnContext(ctx, "warn")
+		l.ErrorContext(ctx, "error")
+		l.Debugw("debug")
+		assert.Equal(t, `[D] debug trace=abc span=1 k=v
+[I] info trace=abc span=1
+[W] warn trace=abc span=1
+[E] error trace=abc span=1
+[D] debug
+`, buf.String())
+		buf.Reset()
+
+		assert.PanicsWithValue(t, "[P] panic trace=abc span=1", func() {
+			l.PanicContext(ctx, "panic")
+		})
+		assert.PanicsWithValue(t, "[P] panic a=1", func() {
+			l.Panicw("panic", "a", 1)
+		})
+		buf.Reset()
+	})
+
+	t.Run("PackageFunction", func(t *testing.T) {
+		assert.Nil(t, SetLogger("plain", plainLogger{l}))
+		SetDefaultLogger("plain")
+		defer func() {
+			SetDefaultLogger(ConfigKey)
+			assert.Nil(t, DeleteLogger("plain"))
+		}()
+
+		ctx := WithLogFields(context.Background(), "trace", "abc")
+		Debugw("debug", "a", 1)
+		Infow("info", "a", 1)
+		Warnw("warn", "a", 1)
+		Errorw("error", "a", 1)
+		DebugContext(ctx, "debug")
+		InfoContext(ctx, "info")
+		WarnContext(ctx, "warn")
+		ErrorContext(ctx, "error")
+		assert.Equal(t, `[D] debug a=1
+[I] info a=1
+[W] warn a=1
+[E] error a=1
+[D] debug trace=abc
+[I] info trace=abc
+[W] warn trace=abc
+[E] error trace=abc
+`, buf.String())
+
+		assert.Panics(t, func() {
+			Panicw("panic", "a", 1)
+		})
+		assert.Panics(t, func() {
+			PanicContext(ctx, "panic")
+		})
+		// Fatalw("fatal")
+		// FatalContext(ctx, "fatal")
+	})
+}
+
+func TestLogCaller(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := newLogger("caller", LogFlag(log.Lshortfile), defaultTao.Log.CallDepth, &logOutput{w: buf})
+	assert.Nil(t, SetLogger("caller", l))
+	SetDefaultLogger("caller")
+	defer func() {
+		SetDefaultLogger(ConfigKey)
+		assert.Nil(t, DeleteLogger("caller"))
+	}()
+
+	for name, call := range map[string]func(){
+		"Direct":          func() { l.Infow("direct") },
+		"Named":           func() { l.Named("redis").Infow("named") },
+		"With":            func() { l.With("a", 1).Infow("with") },
+		"PackageFunction": func() { Infow("package") },
+		"Printf":          func() { Infof("%s", "package") },
+		"Broadcast":       func() { Broadcast().Named("redis").Infow("broadcast") },
+	} {
+		t.Run(name, func(t *testing.T) {
+			call()
+			assert.True(t, strings.HasPrefix(buf.String(), "log_test.go:"), buf.String())
+			buf.Reset()
+		})
+	}
+}
+
+func TestLogLevel(t *testing.T) {
+	buf := new(bytes.Buffer)
+	redis := newLogger("redis", 0, 2, &logOutput{w: buf})
+	defer func() {
+		ResetLogLevel("redis")
+		ResetLogLevel("redis.pool")
+	}()
+
+	t.Run("Config", func(t *testing.T) {
+		c := new(Log)
+		assert.Nil(t, json.Unmarshal([]byte(`{"levels":{"redis":"debug","http":"warn"}}`), c))
+		assert.Equal(t, map[string]LogLevel{"redis": DEBUG, "http": WARNING}, c.Levels)
+	})
+
+	t.Run("Named", func(t *testing.T) {
+		pool := redis.Named("pool")
+		assert.Equal(t, "redis.pool", pool.(*logger).key)
+		assert.Equal(t, "pool", newLogger("", 0, 2).Named("pool").(*logger).key)
+		assert.Equal(t, "tao.redis", Named("redis").(*logger).key)
+
+		pool.With("conn", 1).Infow("get")
+		assert.Equal(t, "[I] get conn=1\n", buf.String())
+		buf.Reset()
+	})
+
+	t.Run("SetLogLevel", func(t *testing.T) {
+		assert.NotNil(t, SetLogLevel("redis", FATAL+1))
+		assert.Nil(t, SetLogLevel("redis", WARNING))
+		assert.Equal(t, WARNING, GetLogLevel("redis"))
+		assert.Equal(t, WARNING, GetLogLevel("tao.redis.pool"))
+		assert.Equal(t, logConfig().Level, GetLogLevel("http"))
+		assert.Equal(t, logConfig().Level, GetLogLevel(""))
+
+		pool := redis.Named("pool")
+		redis.Info("ignored")
+		pool.Info("ignored")
+		redis.Warn("redis")
+		assert.Nil(t, SetLogLevel("redis.pool", DEBUG))
+		pool.Debug("pool")
+		redis.Debug("ignored")
+		assert.Equal(t, "[W] redis\n[D] pool\n", buf.String())
+		buf.Reset()
+
+		ResetLogLevel("redis")
+		ResetLogLevel("redis")
+		assert.Equal(t, logConfig().Level, GetLogLevel("redis"))
+		assert.Equal(t, DEBUG, GetLogLevel("redis.pool"))
+	})
+}
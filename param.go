@@ -15,8 +15,17 @@
 package tao
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
 // Parameter describe function input or output
@@ -26,8 +35,27 @@ type Parameter interface {
 	Clone() Parameter
 	Delete(key string)
 	String() string
+
+	// Keys in sorted order
+	Keys() []string
+	// Range calls f for each key & value until f returns false
+	Range(f func(key string, val interface{}) bool)
+	Len() int
+	Merge(other Parameter, strategy MergeStrategy) error
 }
 
+// MergeStrategy of Parameter.Merge for keys existed in both
+type MergeStrategy uint8
+
+const (
+	// MergeOverwrite values of other overwrite existing ones
+	MergeOverwrite MergeStrategy = iota
+	// MergeKeep existing values are kept
+	MergeKeep
+	// MergeError ParamInvalid returned & nothing merged if any key existed
+	MergeError
+)
+
 var _ Parameter = (*param)(nil)
 
 // param store params
@@ -36,13 +64,58 @@ type param struct {
 	mu sync.RWMutex
 
 	params map[string]interface{}
+
+	// deep to deep clone values, frozen to forbid Set & Delete
+	deep   bool
+	frozen bool
+	// hints of value types to unmarshal
+	hints  map[string]reflect.Type
+	nested map[string]*param
+
+	watchers map[string]map[chan ParamEvent]struct{}
+}
+
+// ParameterOption optional function of param
+type ParameterOption func(p *param)
+
+// SetDeepClone of param, maps, slices & Parameters in values are copied by Clone
+// instead of shared with the cloned one
+func SetDeepClone(deep bool) ParameterOption {
+	return func(p *param) {
+		p.deep = deep
+	}
+}
+
+// SetTypeHint of param, value of key is unmarshalled into the type of sample
+// sample of Parameter keeps its own hints for nested values
+func SetTypeHint(key string, sample interface{}) ParameterOption {
+	return func(p *param) {
+		if p.hints == nil {
+			p.hints = make(map[string]reflect.Type)
+		}
+		if sp, ok := sample.(*param); ok {
+			p.hints[key] = reflect.TypeOf(sp)
+			if p.nested == nil {
+				p.nested = make(map[string]*param)
+			}
+			p.nested[key] = sp
+			return
+		}
+		p.hints[key] = reflect.TypeOf(sample)
+	}
 }
 
 // NewParameter constructor of Parameter
-func NewParameter() Parameter {
-	return &param{
+func NewParameter(options ...ParameterOption) Parameter {
+	p := &param{
 		params: make(map[string]interface{}),
 	}
+
+	for _, option := range options {
+		option(p)
+	}
+
+	return p
 }
 
 // Get value with key
@@ -56,27 +129,100 @@ func (p *param) Get(key string) (value interface{}) {
 func (p *param) Set(key string, value interface{}) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	if p.frozen {
+		panic(NewError(ParamImmutable, "param: Set %q of frozen parameter", key))
+	}
 	p.params[key] = value
+	p.notify(ParamEvent{Key: key, Value: value})
 }
 
 // Delete value with key
 func (p *param) Delete(key string) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	if p.frozen {
+		panic(NewError(ParamImmutable, "param: Delete %q of frozen parameter", key))
+	}
+	if _, ok := p.params[key]; !ok {
+		return
+	}
 	delete(p.params, key)
+	p.notify(ParamEvent{Key: key, Deleted: true})
 }
 
-// Clone param
+// Clone param, the cloned one is always mutable
 func (p *param) Clone() Parameter {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
+	c := &param{
+		params: make(map[string]interface{}, len(p.params)),
+		deep:   p.deep,
+		hints:  p.hints,
+		nested: p.nested,
+	}
+	// p referenced by its values is copied to c
+	seen := map[*param]*param{p: c}
+	for k, v := range p.params {
+		if p.deep {
+			v = deepCopy(v, seen)
+		}
+		c.params[k] = v
+	}
+	return c
+}
+
+// Keys of param in sorted order
+func (p *param) Keys() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	keys := make([]string, 0, len(p.params))
+	for k := range p.params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Range calls f for each key & value in sorted order until f returns false
+// f runs on a snapshot, so it's safe to change param in f
+func (p *param) Range(f func(key string, val interface{}) bool) {
+	p.mu.RLock()
 	m := make(map[string]interface{}, len(p.params))
 	for k, v := range p.params {
 		m[k] = v
 	}
-	return &param{
-		params: m,
+	p.mu.RUnlock()
+	rangeSorted(m, f)
+}
+
+// Len of param
+func (p *param) Len() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.params)
+}
+
+// Merge other into param by strategy
+func (p *param) Merge(other Parameter, strategy MergeStrategy) error {
+	if other == nil {
+		return nil
+	}
+	m := make(map[string]interface{}, other.Len())
+	other.Range(func(key string, val interface{}) bool {
+		m[key] = val
+		return true
+	})
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.frozen {
+		return NewError(ParamImmutable, "param: Merge into frozen parameter")
+	}
+	merged, err := mergeMap(p.params, m, strategy)
+	for _, k := range merged {
+		p.notify(ParamEvent{Key: k, Value: p.params[k]})
 	}
+	return err
 }
 
 // String of param
@@ -94,3 +240,741 @@ func (p *param) MarshalJSON() ([]byte, error) {
 	defer p.mu.RUnlock()
 	return json.Marshal(p.params)
 }
+
+// UnmarshalJSON to unmarshal param by type hints
+// values without hint are decoded as json.Unmarshal into interface{}
+func (p *param) UnmarshalJSON(data []byte) error {
+	raws := make(map[string]json.RawMessage)
+	err := json.Unmarshal(data, &raws)
+	if err != nil {
+		return NewErrorWrapped("param: fail to unmarshal json", err)
+	}
+
+	return p.unmarshal(len(raws), func(f func(key string, decode func(v interface{}) error) error) error {
+		for key, raw := range raws {
+			raw := raw
+			if e := f(key, func(v interface{}) error { return json.Unmarshal(raw, v) }); e != nil {
+				return e
+			}
+		}
+		return nil
+	})
+}
+
+// MarshalYAML to marshal param
+func (p *param) MarshalYAML() (interface{}, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	m := make(map[string]interface{}, len(p.params))
+	for k, v := range p.params {
+		m[k] = v
+	}
+	return m, nil
+}
+
+// UnmarshalYAML to unmarshal param by type hints
+func (p *param) UnmarshalYAML(value *yaml.Node) error {
+	nodes := make(map[string]yaml.Node)
+	err := value.Decode(&nodes)
+	if err != nil {
+		return NewErrorWrapped("param: fail to unmarshal yaml", err)
+	}
+
+	return p.unmarshal(len(nodes), func(f func(key string, decode func(v interface{}) error) error) error {
+		for key, node := range nodes {
+			node := node
+			if e := f(key, node.Decode); e != nil {
+				return e
+			}
+		}
+		return nil
+	})
+}
+
+// unmarshal values decoded by each into param
+func (p *param) unmarshal(size int, each func(f func(key string, decode func(v interface{}) error) error) error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.frozen {
+		return NewError(ParamImmutable, "param: unmarshal into frozen parameter")
+	}
+
+	m := make(map[string]interface{}, size)
+	err := each(func(key string, decode func(v interface{}) error) error {
+		var v interface{}
+		if typ, ok := p.hints[key]; ok {
+			ptr := reflect.New(typ)
+			if sample, ok := p.nested[key]; ok {
+				ptr.Elem().Set(reflect.ValueOf(&param{
+					params: make(map[string]interface{}),
+					deep:   sample.deep,
+					hints:  sample.hints,
+					nested: sample.nested,
+				}))
+			}
+			if e := decode(ptr.Interface()); e != nil {
+				return NewErrorWrapped(fmt.Sprintf("param: fail to unmarshal %q into %s", key, typ), e)
+			}
+			v = ptr.Elem().Interface()
+		} else if e := decode(&v); e != nil {
+			return NewErrorWrapped(fmt.Sprintf("param: fail to unmarshal %q", key), e)
+		}
+		m[key] = v
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if p.params == nil {
+		p.params = make(map[string]interface{}, size)
+	}
+	for k, v := range m {
+		p.params[k] = v
+		p.notify(ParamEvent{Key: k, Value: v})
+	}
+	return nil
+}
+
+// rangeSorted calls f in sorted order of keys until f returns false
+func rangeSorted(m map[string]interface{}, f func(key string, val interface{}) bool) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !f(k, m[k]) {
+			return
+		}
+	}
+}
+
+// mergeMap of src into dst by strategy, keys merged are returned
+func mergeMap(dst, src map[string]interface{}, strategy MergeStrategy) ([]string, error) {
+	switch strategy {
+	case MergeOverwrite:
+	case MergeKeep:
+	case MergeError:
+		for k := range src {
+			if _, ok := dst[k]; ok {
+				return nil, NewError(ParamInvalid, "param: key %q existed when merge", k)
+			}
+		}
+	default:
+		return nil, NewError(ParamInvalid, "param: unknown merge strategy %d", strategy)
+	}
+
+	merged := make([]string, 0, len(src))
+	for k, v := range src {
+		if _, ok := dst[k]; ok && strategy == MergeKeep {
+			continue
+		}
+		dst[k] = v
+		merged = append(merged, k)
+	}
+	return merged, nil
+}
+
+// UpstreamPrefix of key to get result of runAfter task in pipeline
+// e.g. param.Get(UpstreamPrefix + "taskName")
+const UpstreamPrefix = "upstream."
+
+// Upstream result of runAfter task in pipeline, nil if not found
+func Upstream(param Parameter, name string) Parameter {
+	if param == nil {
+		return nil
+	}
+	result, _ := param.Get(UpstreamPrefix + name).(Parameter)
+	return result
+}
+
+var _ ParameterWatcher = (*upstreamParam)(nil)
+
+// upstreamParam view of Parameter with results of runAfter tasks
+// results are read only & not part of Clone or String
+type upstreamParam struct {
+	Parameter
+
+	upstream map[string]Parameter
+}
+
+// Get value with key, results of runAfter tasks first
+func (u *upstreamParam) Get(key string) interface{} {
+	if strings.HasPrefix(key, UpstreamPrefix) {
+		if result, ok := u.upstream[strings.TrimPrefix(key, UpstreamPrefix)]; ok {
+			return result
+		}
+	}
+	return u.Parameter.Get(key)
+}
+
+// Watch key of Parameter under upstream view
+func (u *upstreamParam) Watch(ctx context.Context, key string) <-chan ParamEvent {
+	return watchOrClose(ctx, u.Parameter, key)
+}
+
+// MarshalJSON to marshal param without results of runAfter tasks
+func (u *upstreamParam) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u.Parameter)
+}
+
+// GetAs value of key in type T
+// ParamInvalid error returned if key not found or value is not T
+func GetAs[T any](p Parameter, key string) (T, error) {
+	return getWith(p, key, func(v interface{}) (T, bool) {
+		t, ok := v.(T)
+		return t, ok
+	})
+}
+
+// GetAsOr value of key in type T, def returned if key not found or value is not T
+func GetAsOr[T any](p Parameter, key string, def T) T {
+	v, err := GetAs[T](p, key)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// GetStringE value of key as string
+func GetStringE(p Parameter, key string) (string, error) {
+	return getWith(p, key, toString)
+}
+
+// GetString value of key as string, "" if not found or invalid
+func GetString(p Parameter, key string) string {
+	v, _ := GetStringE(p, key)
+	return v
+}
+
+// GetStringOr value of key as string, def if not found or invalid
+func GetStringOr(p Parameter, key string, def string) string {
+	v, err := GetStringE(p, key)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// GetIntE value of key as int, numbers & numeric strings are converted
+func GetIntE(p Parameter, key string) (int, error) {
+	return getWith(p, key, toInt)
+}
+
+// GetInt value of key as int, 0 if not found or invalid
+func GetInt(p Parameter, key string) int {
+	v, _ := GetIntE(p, key)
+	return v
+}
+
+// GetIntOr value of key as int, def if not found or invalid
+func GetIntOr(p Parameter, key string, def int) int {
+	v, err := GetIntE(p, key)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// GetBoolE value of key as bool, strings like "true" are converted
+func GetBoolE(p Parameter, key string) (bool, error) {
+	return getWith(p, key, toBool)
+}
+
+// GetBool value of key as bool, false if not found or invalid
+func GetBool(p Parameter, key string) bool {
+	v, _ := GetBoolE(p, key)
+	return v
+}
+
+// GetBoolOr value of key as bool, def if not found or invalid
+func GetBoolOr(p Parameter, key string, def bool) bool {
+	v, err := GetBoolE(p, key)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// GetDurationE value of key as time.Duration
+// strings like "1s" are parsed & integers are taken as nanoseconds
+func GetDurationE(p Parameter, key string) (time.Duration, error) {
+	return getWith(p, key, toDuration)
+}
+
+// GetDuration value of key as time.Duration, 0 if not found or invalid
+func GetDuration(p Parameter, key string) time.Duration {
+	v, _ := GetDurationE(p, key)
+	return v
+}
+
+// GetDurationOr value of key as time.Duration, def if not found or invalid
+func GetDurationOr(p Parameter, key string, def time.Duration) time.Duration {
+	v, err := GetDurationE(p, key)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+func getWith[T any](p Parameter, key string, convert func(v interface{}) (T, bool)) (T, error) {
+	var zero T
+	if p == nil {
+		return zero, NewError(ParamInvalid, "param: get %q from nil parameter", key)
+	}
+	v := p.Get(key)
+	if v == nil {
+		return zero, NewError(ParamInvalid, "param: %q not found", key)
+	}
+	t, ok := convert(v)
+	if !ok {
+		return zero, NewError(ParamInvalid, "param: %q is %T which can't be %s", key, v, typeName[T]())
+	}
+	return t, nil
+}
+
+func typeName[T any]() string {
+	return reflect.TypeOf((*T)(nil)).Elem().String()
+}
+
+func toString(v interface{}) (string, bool) {
+	switch s := v.(type) {
+	case string:
+		return s, true
+	case []byte:
+		return string(s), true
+	}
+
+	// named string types
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.String {
+		return rv.String(), true
+	}
+	return "", false
+}
+
+func toInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case string:
+		i, err := strconv.Atoi(n)
+		return i, err == nil
+	case json.Number:
+		i, err := strconv.Atoi(n.String())
+		return i, err == nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := rv.Int()
+		return int(i), i >= math.MinInt && i <= math.MaxInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := rv.Uint()
+		return int(u), u <= math.MaxInt
+	case reflect.Float32, reflect.Float64:
+		// numbers of json are float64
+		f := rv.Float()
+		return int(f), f == math.Trunc(f) && f >= math.MinInt && f < math.MaxInt
+	default:
+		return 0, false
+	}
+}
+
+func toBool(v interface{}) (bool, bool) {
+	switch b := v.(type) {
+	case bool:
+		return b, true
+	case string:
+		parsed, err := strconv.ParseBool(b)
+		return parsed, err == nil
+	}
+
+	// named bool types
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Bool {
+		return rv.Bool(), true
+	}
+	return false, false
+}
+
+func toDuration(v interface{}) (time.Duration, bool) {
+	switch d := v.(type) {
+	case time.Duration:
+		return d, true
+	case string:
+		parsed, err := time.ParseDuration(d)
+		return parsed, err == nil
+	}
+	i, ok := toInt(v)
+	return time.Duration(i), ok
+}
+
+// Key typed key of Parameter
+// stored as "owner.name(type)", so keys of different owners or types never collide
+type Key[T any] struct {
+	key string
+}
+
+// NewKey constructor of Key, owner is the unit or task which the key belongs to
+// keys with empty owner are stored as "name(type)" & shared by all
+func NewKey[T any](owner, name string) Key[T] {
+	if owner != "" {
+		name = owner + "." + name
+	}
+	return Key[T]{key: name + "(" + typeName[T]() + ")"}
+}
+
+// String key stored in Parameter
+func (k Key[T]) String() string {
+	return k.key
+}
+
+// Get value of key, ParamInvalid error returned if not found
+func (k Key[T]) Get(p Parameter) (T, error) {
+	return GetAs[T](p, k.key)
+}
+
+// GetOr value of key, def returned if not found
+func (k Key[T]) GetOr(p Parameter, def T) T {
+	return GetAsOr(p, k.key, def)
+}
+
+// Set value of key
+func (k Key[T]) Set(p Parameter, val T) {
+	p.Set(k.key, val)
+}
+
+// Delete value of key
+func (k Key[T]) Delete(p Parameter) {
+	p.Delete(k.key)
+}
+
+// ParamEvent of key changed in Parameter
+type ParamEvent struct {
+	Key     string
+	Value   interface{}
+	Deleted bool
+}
+
+// ParameterWatcher describe Parameter whose keys can be watched
+type ParameterWatcher interface {
+	Parameter
+	// Watch key until ctx done, then the channel is closed
+	// current value is sent first if key existed, only the latest event is kept for slow consumers
+	Watch(ctx context.Context, key string) <-chan ParamEvent
+}
+
+var _ ParameterWatcher = (*param)(nil)
+
+// Watch key of Parameter until ctx done
+// ParamInvalid returned if p can't be watched
+func Watch(ctx context.Context, p Parameter, key string) (<-chan ParamEvent, error) {
+	w, ok := p.(ParameterWatcher)
+	if !ok {
+		return nil, NewError(ParamInvalid, "param: %T can't be watched", p)
+	}
+	return w.Watch(ctx, key), nil
+}
+
+// Watch key of param until ctx done
+func (p *param) Watch(ctx context.Context, key string) <-chan ParamEvent {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ch := make(chan ParamEvent, 1)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if v, ok := p.params[key]; ok {
+		ch <- ParamEvent{Key: key, Value: v}
+	}
+	if p.watchers == nil {
+		p.watchers = make(map[string]map[chan ParamEvent]struct{})
+	}
+	if p.watchers[key] == nil {
+		p.watchers[key] = make(map[chan ParamEvent]struct{})
+	}
+	p.watchers[key][ch] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		delete(p.watchers[key], ch)
+		if len(p.watchers[key]) == 0 {
+			delete(p.watchers, key)
+		}
+		close(ch)
+	}()
+	return ch
+}
+
+// notify watchers of key with lock held, stale event is replaced by the latest one
+func (p *param) notify(event ParamEvent) {
+	for ch := range p.watchers[event.Key] {
+		select {
+		case ch <- event:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- event
+		}
+	}
+}
+
+// DeepClone of Parameter, maps, slices, arrays & Parameters in values are copied recursively
+// pointers & other values are still shared
+func DeepClone(p Parameter) Parameter {
+	if p == nil {
+		return nil
+	}
+	if _, ok := p.(*param); !ok {
+		// flatten other implementations first
+		p = p.Clone()
+	}
+	return deepCopy(p, make(map[*param]*param)).(Parameter)
+}
+
+// Freeze Parameter to an immutable deep cloned one
+// Set & Delete of it panic with ParamImmutable ErrorTao
+func Freeze(p Parameter) Parameter {
+	frozen := &param{
+		params: make(map[string]interface{}),
+		deep:   true,
+		frozen: true,
+	}
+	if p == nil {
+		return frozen
+	}
+	if v, ok := DeepClone(p).(*param); ok {
+		frozen.params = v.params
+		return frozen
+	}
+	// other implementations are flattened by json
+	bytes, err := json.Marshal(p)
+	if err == nil {
+		_ = json.Unmarshal(bytes, &frozen.params)
+	}
+	return frozen
+}
+
+// deepCopy value of param
+func deepCopy(v interface{}, seen map[*param]*param) interface{} {
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case *param:
+		if t == nil {
+			return v
+		}
+		// param referencing itself is copied once, cycles of other Parameters are not handled
+		if c, ok := seen[t]; ok {
+			return c
+		}
+		c := &param{params: make(map[string]interface{}), deep: t.deep, hints: t.hints, nested: t.nested}
+		seen[t] = c
+		t.mu.RLock()
+		defer t.mu.RUnlock()
+		for k, val := range t.params {
+			c.params[k] = deepCopy(val, seen)
+		}
+		return c
+	case Parameter:
+		if rv := reflect.ValueOf(t); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return v
+		}
+		return t.Clone()
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.IsNil() {
+			return v
+		}
+		m := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			m.SetMapIndex(iter.Key(), deepCopyValue(iter.Value(), rv.Type().Elem(), seen))
+		}
+		return m.Interface()
+	case reflect.Slice:
+		if rv.IsNil() {
+			return v
+		}
+		s := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			s.Index(i).Set(deepCopyValue(rv.Index(i), rv.Type().Elem(), seen))
+		}
+		return s.Interface()
+	case reflect.Array:
+		a := reflect.New(rv.Type()).Elem()
+		for i := 0; i < rv.Len(); i++ {
+			a.Index(i).Set(deepCopyValue(rv.Index(i), rv.Type().Elem(), seen))
+		}
+		return a.Interface()
+	default:
+		return v
+	}
+}
+
+// deepCopyValue of reflect.Value, which is assignable to typ
+func deepCopyValue(v reflect.Value, typ reflect.Type, seen map[*param]*param) reflect.Value {
+	if !v.CanInterface() || (v.Kind() == reflect.Interface && v.IsNil()) {
+		return v
+	}
+	c := reflect.ValueOf(deepCopy(v.Interface(), seen))
+	if !c.IsValid() {
+		return reflect.Zero(typ)
+	}
+	return c
+}
+
+var _ ParameterWatcher = (*scopeParam)(nil)
+
+// scopeParam child of Parameter
+// reads through to parent but writes locally
+type scopeParam struct {
+	mu sync.RWMutex
+
+	parent  Parameter
+	local   map[string]interface{}
+	deleted map[string]struct{}
+}
+
+// NewScope of parent Parameter, which reads through to parent but writes locally
+// parent is never changed by the scope
+func NewScope(parent Parameter) Parameter {
+	if parent == nil {
+		parent = NewParameter()
+	}
+	return &scopeParam{
+		parent:  parent,
+		local:   make(map[string]interface{}),
+		deleted: make(map[string]struct{}),
+	}
+}
+
+// Get value with key, local value first
+func (s *scopeParam) Get(key string) interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if v, ok := s.local[key]; ok {
+		return v
+	}
+	if _, ok := s.deleted[key]; ok {
+		return nil
+	}
+	return s.parent.Get(key)
+}
+
+// Set value with key locally
+func (s *scopeParam) Set(key string, val interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.local[key] = val
+	delete(s.deleted, key)
+}
+
+// Delete value with key locally, the one of parent is hidden
+func (s *scopeParam) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.local, key)
+	s.deleted[key] = struct{}{}
+}
+
+// Clone scope to a flattened Parameter of parent & local values
+func (s *scopeParam) Clone() Parameter {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c := s.parent.Clone()
+	deep := false
+	if p, ok := c.(*param); ok {
+		deep = p.deep
+	}
+	for key := range s.deleted {
+		c.Delete(key)
+	}
+	for key, val := range s.local {
+		if deep {
+			val = deepCopy(val, make(map[*param]*param))
+		}
+		c.Set(key, val)
+	}
+	return c
+}
+
+// Keys of scope in sorted order
+func (s *scopeParam) Keys() []string {
+	return s.Clone().Keys()
+}
+
+// Range calls f for each key & value of scope in sorted order until f returns false
+func (s *scopeParam) Range(f func(key string, val interface{}) bool) {
+	s.Clone().Range(f)
+}
+
+// Len of scope
+func (s *scopeParam) Len() int {
+	return s.Clone().Len()
+}
+
+// Merge other into scope locally by strategy
+func (s *scopeParam) Merge(other Parameter, strategy MergeStrategy) error {
+	if other == nil {
+		return nil
+	}
+	src := make(map[string]interface{}, other.Len())
+	other.Range(func(key string, val interface{}) bool {
+		src[key] = val
+		return true
+	})
+
+	// existing keys of parent & local
+	dst := make(map[string]interface{})
+	s.Range(func(key string, val interface{}) bool {
+		dst[key] = val
+		return true
+	})
+	merged, err := mergeMap(dst, src, strategy)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range merged {
+		s.local[key] = dst[key]
+		delete(s.deleted, key)
+	}
+	return nil
+}
+
+// Watch key of parent, values set in scope locally are not watched
+func (s *scopeParam) Watch(ctx context.Context, key string) <-chan ParamEvent {
+	return watchOrClose(ctx, s.parent, key)
+}
+
+// watchOrClose watches key of p, or returns a closed channel if p can't be watched
+func watchOrClose(ctx context.Context, p Parameter, key string) <-chan ParamEvent {
+	if w, ok := p.(ParameterWatcher); ok {
+		return w.Watch(ctx, key)
+	}
+	ch := make(chan ParamEvent)
+	close(ch)
+	return ch
+}
+
+// String of scope
+func (s *scopeParam) String() string {
+	return s.Clone().String()
+}
+
+// MarshalJSON to marshal flattened scope
+func (s *scopeParam) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Clone())
+}
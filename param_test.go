@@ -15,9 +15,13 @@
 package tao
 
 import (
+	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -53,3 +57,379 @@ func TestNewParameter(t *testing.T) {
 		assert.NotSame(t, param2, parameter)
 	})
 }
+
+func TestParamTyped(t *testing.T) {
+	p := NewParameter()
+	p.Set("string", "tao")
+	p.Set("int", 42)
+	p.Set("float", float64(7))
+	p.Set("bool", "true")
+	p.Set("duration", "1m")
+	p.Set("nanos", int64(time.Second))
+
+	t.Run("GetAs", func(t *testing.T) {
+		s, err := GetAs[string](p, "string")
+		assert.Nil(t, err)
+		assert.Equal(t, "tao", s)
+
+		_, err = GetAs[int](p, "string")
+		assert.Equal(t, ParamInvalid, err.(ErrorTao).Code())
+		_, err = GetAs[int](p, "unknown")
+		assert.Equal(t, ParamInvalid, err.(ErrorTao).Code())
+		_, err = GetAs[int](nil, "int")
+		assert.Equal(t, ParamInvalid, err.(ErrorTao).Code())
+
+		assert.Equal(t, 42, GetAsOr(p, "int", 0))
+		assert.Equal(t, 1, GetAsOr(p, "string", 1))
+	})
+
+	t.Run("GetString", func(t *testing.T) {
+		assert.Equal(t, "tao", GetString(p, "string"))
+		assert.Equal(t, "", GetString(p, "int"))
+		assert.Equal(t, "def", GetStringOr(p, "unknown", "def"))
+		_, err := GetStringE(p, "int")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("GetInt", func(t *testing.T) {
+		assert.Equal(t, 42, GetInt(p, "int"))
+		assert.Equal(t, 7, GetInt(p, "float"))
+		assert.Equal(t, 0, GetInt(p, "string"))
+		assert.Equal(t, 1, GetIntOr(p, "bool", 1))
+		p.Set("float", 7.5)
+		_, err := GetIntE(p, "float")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Named", func(t *testing.T) {
+		type myInt int
+		type myString string
+		type myBool bool
+		named := NewParameter()
+		named.Set("int", myInt(3))
+		named.Set("string", myString("tao"))
+		named.Set("bool", myBool(true))
+
+		n, err := GetIntE(named, "int")
+		assert.Nil(t, err)
+		assert.Equal(t, 3, n)
+		assert.Equal(t, time.Duration(3), GetDuration(named, "int"))
+		assert.Equal(t, "tao", GetString(named, "string"))
+		assert.Equal(t, true, GetBool(named, "bool"))
+	})
+
+	t.Run("GetBool", func(t *testing.T) {
+		assert.Equal(t, true, GetBool(p, "bool"))
+		assert.Equal(t, false, GetBool(p, "int"))
+		assert.Equal(t, true, GetBoolOr(p, "unknown", true))
+		_, err := GetBoolE(p, "string")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("GetDuration", func(t *testing.T) {
+		assert.Equal(t, time.Minute, GetDuration(p, "duration"))
+		assert.Equal(t, time.Second, GetDuration(p, "nanos"))
+		assert.Equal(t, time.Hour, GetDurationOr(p, "string", time.Hour))
+		_, err := GetDurationE(p, "unknown")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Key", func(t *testing.T) {
+		port := NewKey[int]("", "port")
+		portName := NewKey[string]("", "port")
+		assert.Equal(t, "port(int)", port.String())
+
+		// same name & type of different owners
+		producerAddr := NewKey[string]("producer", "addr")
+		consumerAddr := NewKey[string]("consumer", "addr")
+		assert.Equal(t, "producer.addr(string)", producerAddr.String())
+		producerAddr.Set(p, "127.0.0.1:8080")
+		consumerAddr.Set(p, "127.0.0.1:9090")
+		assert.Equal(t, "127.0.0.1:8080", producerAddr.GetOr(p, ""))
+		assert.Equal(t, "127.0.0.1:9090", consumerAddr.GetOr(p, ""))
+
+		port.Set(p, 8080)
+		portName.Set(p, "http")
+		v, err := port.Get(p)
+		assert.Nil(t, err)
+		assert.Equal(t, 8080, v)
+		assert.Equal(t, "http", portName.GetOr(p, ""))
+
+		port.Delete(p)
+		_, err = port.Get(p)
+		assert.NotNil(t, err)
+		assert.Equal(t, 80, port.GetOr(p, 80))
+	})
+}
+
+func TestParamClone(t *testing.T) {
+	newParam := func(options ...ParameterOption) Parameter {
+		nested := NewParameter()
+		nested.Set("n", 1)
+		p := NewParameter(options...)
+		p.Set("map", map[string]interface{}{"k": []int{1, 2}})
+		p.Set("slice", []string{"a", "b"})
+		p.Set("param", nested)
+		return p
+	}
+	mutate := func(p Parameter) {
+		p.Get("map").(map[string]interface{})["k"].([]int)[0] = 100
+		p.Get("slice").([]string)[0] = "z"
+		p.Get("param").(Parameter).Set("n", 2)
+	}
+
+	t.Run("Shallow", func(t *testing.T) {
+		p := newParam()
+		c := p.Clone()
+		mutate(p)
+		assert.Equal(t, "z", c.Get("slice").([]string)[0])
+	})
+
+	t.Run("DeepClone", func(t *testing.T) {
+		p := newParam()
+		c := DeepClone(p)
+		mutate(p)
+		assert.Equal(t, `{"map":{"k":[1,2]},"param":{"n":1},"slice":["a","b"]}`, c.String())
+		assert.Nil(t, DeepClone(nil))
+	})
+
+	t.Run("SetDeepClone", func(t *testing.T) {
+		p := newParam(SetDeepClone(true))
+		c := p.Clone()
+		mutate(p)
+		assert.Equal(t, `{"map":{"k":[1,2]},"param":{"n":1},"slice":["a","b"]}`, c.String())
+
+		// result of task is deep cloned
+		task := NewTask("deep", func(ctx context.Context, param Parameter) (Parameter, error) {
+			return param, nil
+		})
+		p = newParam(SetDeepClone(true))
+		assert.Nil(t, task.Run(context.Background(), p))
+		mutate(p)
+		assert.Equal(t, `{"map":{"k":[1,2]},"param":{"n":1},"slice":["a","b"]}`, task.Result().String())
+	})
+
+	t.Run("NilAndCycle", func(t *testing.T) {
+		p := NewParameter(SetDeepClone(true))
+		p.Set("params", []*param{nil})
+		p.Set("scope", (*scopeParam)(nil))
+		p.Set("self", p)
+		c := p.Clone()
+		assert.Equal(t, []*param{nil}, c.Get("params"))
+		assert.Nil(t, c.Get("scope"))
+		// reference to itself is kept in the clone
+		assert.Same(t, c, c.Get("self"))
+		d := DeepClone(p)
+		assert.Same(t, d, d.Get("self"))
+	})
+
+	t.Run("Freeze", func(t *testing.T) {
+		p := newParam()
+		f := Freeze(p)
+		mutate(p)
+		assert.Equal(t, `{"map":{"k":[1,2]},"param":{"n":1},"slice":["a","b"]}`, f.String())
+
+		func() {
+			defer func() {
+				assert.Equal(t, ParamImmutable, recover().(ErrorTao).Code())
+			}()
+			f.Set("key", "value")
+		}()
+		assert.Panics(t, func() {
+			f.Delete("slice")
+		})
+
+		c := f.Clone()
+		c.Set("key", "value")
+		assert.Equal(t, "value", c.Get("key"))
+		assert.Nil(t, f.Get("key"))
+
+		assert.Equal(t, "{}", Freeze(nil).String())
+		assert.Equal(t, `{"map":{"k":[100,2]},"param":{"n":2},"slice":["z","b"]}`, Freeze(NewScope(p)).String())
+	})
+
+	t.Run("Scope", func(t *testing.T) {
+		parent := NewParameter()
+		parent.Set("a", 1)
+		parent.Set("b", 2)
+
+		s := NewScope(parent)
+		assert.Equal(t, 1, s.Get("a"))
+		s.Set("a", 10)
+		s.Delete("b")
+		s.Set("c", 3)
+		assert.Equal(t, 10, s.Get("a"))
+		assert.Nil(t, s.Get("b"))
+		assert.Equal(t, `{"a":1,"b":2}`, parent.String())
+		assert.Equal(t, `{"a":10,"c":3}`, s.String())
+
+		s.Set("b", 20)
+		assert.Equal(t, 20, s.Get("b"))
+		assert.Equal(t, `{"a":10,"b":20,"c":3}`, s.Clone().String())
+		assert.Equal(t, 1, NewScope(Freeze(parent)).Clone().Get("a"))
+		assert.Nil(t, NewScope(nil).Get("a"))
+	})
+}
+
+func TestParamIteration(t *testing.T) {
+	p := NewParameter()
+	p.Set("b", 2)
+	p.Set("a", 1)
+	p.Set("c", 3)
+
+	t.Run("Keys", func(t *testing.T) {
+		assert.Equal(t, []string{"a", "b", "c"}, p.Keys())
+		assert.Equal(t, 3, p.Len())
+	})
+
+	t.Run("Range", func(t *testing.T) {
+		keys := make([]string, 0)
+		p.Range(func(key string, val interface{}) bool {
+			keys = append(keys, key)
+			p.Set(key+key, val)
+			return key != "b"
+		})
+		assert.Equal(t, []string{"a", "b"}, keys)
+		assert.Equal(t, 5, p.Len())
+		p.Delete("aa")
+		p.Delete("bb")
+	})
+
+	t.Run("Merge", func(t *testing.T) {
+		other := NewParameter()
+		other.Set("c", 30)
+		other.Set("d", 40)
+
+		keep := p.Clone()
+		assert.Nil(t, keep.Merge(other, MergeKeep))
+		assert.Equal(t, `{"a":1,"b":2,"c":3,"d":40}`, keep.String())
+
+		overwrite := p.Clone()
+		assert.Nil(t, overwrite.Merge(other, MergeOverwrite))
+		assert.Equal(t, `{"a":1,"b":2,"c":30,"d":40}`, overwrite.String())
+
+		conflict := p.Clone()
+		assert.Equal(t, ParamInvalid, conflict.Merge(other, MergeError).(ErrorTao).Code())
+		assert.Equal(t, `{"a":1,"b":2,"c":3}`, conflict.String())
+		assert.Equal(t, ParamInvalid, conflict.Merge(other, MergeStrategy(100)).(ErrorTao).Code())
+		assert.Nil(t, conflict.Merge(nil, MergeError))
+
+		assert.Equal(t, ParamImmutable, Freeze(p).Merge(other, MergeKeep).(ErrorTao).Code())
+
+		scope := NewScope(p)
+		assert.Equal(t, 3, scope.Len())
+		assert.Equal(t, ParamInvalid, scope.Merge(other, MergeError).(ErrorTao).Code())
+		assert.Nil(t, scope.Merge(other, MergeKeep))
+		assert.Equal(t, []string{"a", "b", "c", "d"}, scope.Keys())
+		assert.Equal(t, 3, scope.Get("c"))
+		assert.Equal(t, `{"a":1,"b":2,"c":3}`, p.String())
+	})
+
+	t.Run("Unmarshal", func(t *testing.T) {
+		in := NewParameter()
+		in.Set("name", "tao")
+		in.Set("count", 3)
+		in.Set("timeout", time.Second)
+		nested := NewParameter()
+		nested.Set("ports", []int{80, 443})
+		in.Set("nested", nested)
+
+		hints := []ParameterOption{
+			SetTypeHint("count", 0),
+			SetTypeHint("timeout", time.Duration(0)),
+			SetTypeHint("nested", NewParameter(SetTypeHint("ports", []int{}))),
+		}
+
+		data, err := json.Marshal(in)
+		assert.Nil(t, err)
+		out := NewParameter(hints...)
+		assert.Nil(t, json.Unmarshal(data, out))
+		assert.Equal(t, in.String(), out.String())
+		assert.Equal(t, 3, out.Get("count"))
+		assert.Equal(t, time.Second, out.Get("timeout"))
+		assert.Equal(t, []int{80, 443}, out.Get("nested").(Parameter).Get("ports"))
+
+		plain := NewParameter()
+		assert.Nil(t, json.Unmarshal(data, plain))
+		assert.Equal(t, float64(3), plain.Get("count"))
+		assert.NotNil(t, json.Unmarshal([]byte(`{"count":"three"}`), out))
+		assert.NotNil(t, json.Unmarshal([]byte(`[]`), out))
+
+		data, err = yaml.Marshal(in)
+		assert.Nil(t, err)
+		out = NewParameter(hints...)
+		assert.Nil(t, yaml.Unmarshal(data, out))
+		assert.Equal(t, in.String(), out.String())
+		assert.Equal(t, []int{80, 443}, out.Get("nested").(Parameter).Get("ports"))
+		assert.NotNil(t, yaml.Unmarshal([]byte(`count: three`), out))
+		assert.NotNil(t, yaml.Unmarshal([]byte(`[]`), out))
+
+		assert.Equal(t, ParamImmutable, json.Unmarshal([]byte("{}"), Freeze(nil)).(ErrorTao).Code())
+	})
+}
+
+func TestParamWatch(t *testing.T) {
+	t.Run("Watch", func(t *testing.T) {
+		p := NewParameter()
+		p.Set("exist", 1)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		exist, err := Watch(ctx, p, "exist")
+		assert.Nil(t, err)
+		assert.Equal(t, ParamEvent{Key: "exist", Value: 1}, <-exist)
+
+		ch, err := Watch(ctx, p, "addr")
+		assert.Nil(t, err)
+		p.Set("addr", "a")
+		assert.Equal(t, ParamEvent{Key: "addr", Value: "a"}, <-ch)
+
+		// only the latest one is kept
+		p.Set("addr", "b")
+		p.Set("addr", "c")
+		assert.Equal(t, ParamEvent{Key: "addr", Value: "c"}, <-ch)
+
+		p.Delete("addr")
+		assert.Equal(t, ParamEvent{Key: "addr", Deleted: true}, <-ch)
+		p.Delete("addr")
+
+		other := NewParameter()
+		other.Set("addr", "d")
+		assert.Nil(t, p.Merge(other, MergeKeep))
+		assert.Equal(t, ParamEvent{Key: "addr", Value: "d"}, <-ch)
+		assert.Nil(t, json.Unmarshal([]byte(`{"addr":"e"}`), p))
+		assert.Equal(t, ParamEvent{Key: "addr", Value: "e"}, <-ch)
+
+		cancel()
+		for range ch {
+		}
+		_, ok := <-exist
+		assert.False(t, ok)
+		p.Set("addr", "f")
+	})
+
+	t.Run("Pipeline", func(t *testing.T) {
+		p := NewPipeline("watch")
+		assert.Nil(t, p.Register(NewPipeTask(NewTask("consumer", func(ctx context.Context, param Parameter) (Parameter, error) {
+			ch, err := Watch(ctx, param, "addr")
+			assert.Nil(t, err)
+			assert.Equal(t, ":8080", (<-ch).Value)
+			return param, nil
+		}))))
+		assert.Nil(t, p.Register(NewPipeTask(NewTask("producer", func(ctx context.Context, param Parameter) (Parameter, error) {
+			time.Sleep(10 * time.Millisecond)
+			param.Set("addr", ":8080")
+			return param, nil
+		}))))
+		assert.Nil(t, p.Run(context.Background(), nil))
+	})
+
+	t.Run("Unwatchable", func(t *testing.T) {
+		_, err := Watch(context.Background(), nil, "key")
+		assert.Equal(t, ParamInvalid, err.(ErrorTao).Code())
+
+		ch, err := Watch(context.Background(), NewScope(&upstreamParam{Parameter: nil}), "key")
+		assert.Nil(t, err)
+		_, ok := <-ch
+		assert.False(t, ok)
+	})
+}
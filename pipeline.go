@@ -17,12 +17,36 @@ package tao
 import (
 	"context"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // PipeTask task in Pipeline
 type PipeTask struct {
 	Task
 	runAfter []string
+
+	// weight & groups to limit concurrency
+	weight int
+	groups []string
+
+	// start of running & duration of last run, read by Graph without locks of Task
+	start    atomic.Pointer[taskStart]
+	duration atomic.Int64
+}
+
+// taskStart of PipeTask by clock
+type taskStart struct {
+	clock Clock
+	at    time.Time
+}
+
+// running of task & its elapsed until now, or duration of last run
+func (t *PipeTask) elapsed() (time.Duration, bool) {
+	if start := t.start.Load(); start != nil {
+		return start.clock.Since(start.at), true
+	}
+	return time.Duration(t.duration.Load()), false
 }
 
 // NewPipeTask constructor of PipeTask
@@ -30,9 +54,22 @@ func NewPipeTask(task Task, runAfter ...string) *PipeTask {
 	return &PipeTask{
 		Task:     task,
 		runAfter: runAfter,
+		weight:   1,
 	}
 }
 
+// WithWeight of PipeTask, slots it takes from SetMaxParallel, 1 by default
+func (t *PipeTask) WithWeight(weight int) *PipeTask {
+	t.weight = weight
+	return t
+}
+
+// WithGroups of PipeTask, limited by SetGroupLimit of each group
+func (t *PipeTask) WithGroups(groups ...string) *PipeTask {
+	t.groups = groups
+	return t
+}
+
 // Pipeline to run tasks in order
 // pipeline is also a task
 type Pipeline interface {
@@ -46,17 +83,22 @@ var _ Pipeline = (*pipeline)(nil)
 type pipeline struct {
 	wg sync.WaitGroup
 	mu sync.RWMutex
+	// regMu of tasks & signals written by Register, so they can be read while running
+	regMu sync.RWMutex
 
 	name string
 
 	tasks     []*PipeTask
 	signals   map[string]chan struct{}
-	closeChan chan func() error
+	closeChan chan *PipeTask
 	postStart *PipeTask
 	preStop   *PipeTask
+	limiter   *limiter
+	isolated  bool
+	clock     Clock
 
 	results Parameter
-	err     ErrorTao
+	err     MultiError
 	state   TaskState
 }
 
@@ -108,6 +150,8 @@ func (p *pipeline) Register(task *PipeTask) error {
 		return NewError(ParamInvalid, "pipeline: Register called twice for task %q", tName)
 	}
 
+	p.regMu.Lock()
+	defer p.regMu.Unlock()
 	p.tasks = append(p.tasks, task)
 	p.signals[tName] = make(chan struct{}, 1)
 	return nil
@@ -136,7 +180,7 @@ func (p *pipeline) Run(ctx context.Context, param Parameter) error {
 
 	select {
 	case <-ctx.Done():
-		return NewError(ContextCanceled, "pipeline: context has been canceled")
+		return canceledError(ctx, "pipeline: context has been canceled")
 	default:
 	}
 
@@ -145,20 +189,29 @@ func (p *pipeline) Run(ctx context.Context, param Parameter) error {
 		p.state = Over
 	}()
 
-	// init closeChan & results when run
-	p.closeChan = make(chan func() error, len(p.tasks)+2)
+	if p.clock != nil {
+		ctx = ContextWithClock(ctx, p.clock)
+	}
+
+	// init closeChan, results & err when run
+	p.closeChan = make(chan *PipeTask, len(p.tasks)+2)
 	p.results = NewParameter()
+	p.err = NewMultiError(Unknown)
 
 	if p.postStart != nil {
 		p.taskRun(ctx, p.postStart, param, false)
-		if p.err != nil {
-			return p.err
+		if err := p.error(); err != nil {
+			return err
 		}
 	}
 
-	for _, task := range p.tasks {
-		p.wg.Add(1)
-		go p.taskRun(ctx, task, param, true)
+	if p.limiter != nil && p.limiter.max > 0 {
+		p.runPool(ctx, param)
+	} else {
+		for _, task := range p.tasks {
+			p.wg.Add(1)
+			go p.taskRun(ctx, task, param, true)
+		}
 	}
 	p.wg.Wait()
 
@@ -166,6 +219,101 @@ func (p *pipeline) Run(ctx context.Context, param Parameter) error {
 		p.taskRun(ctx, p.preStop, param, false)
 	}
 
+	return p.error()
+}
+
+// runPool of workers bounded by max parallel
+// tasks are ready after their runAfter tasks done, workers take the first ready one which slots are available
+func (p *pipeline) runPool(ctx context.Context, param Parameter) {
+	var (
+		mu      sync.Mutex
+		changed = make(chan struct{})
+		taken   = 0
+		ready   = make([]*PipeTask, 0, len(p.tasks))
+		pending = make(map[string]int, len(p.tasks))
+		next    = make(map[string][]*PipeTask, len(p.tasks))
+	)
+	for _, task := range p.tasks {
+		for _, pre := range task.runAfter {
+			if _, ok := p.signals[pre]; ok {
+				pending[task.Name()]++
+				next[pre] = append(next[pre], task)
+			}
+		}
+		if pending[task.Name()] == 0 {
+			ready = append(ready, task)
+		}
+	}
+
+	// take task to run, false if all taken
+	take := func() (*PipeTask, bool) {
+		for {
+			mu.Lock()
+			if taken == len(p.tasks) {
+				mu.Unlock()
+				return nil, false
+			}
+			for i, task := range ready {
+				// slots are acquired here, or task fails by ContextCanceled
+				if ctx.Err() != nil || p.limiter.tryAcquire(task) {
+					ready = append(ready[:i], ready[i+1:]...)
+					taken++
+					mu.Unlock()
+					return task, true
+				}
+			}
+			wait := changed
+			mu.Unlock()
+
+			if ctx.Err() != nil {
+				// tasks left are waiting for running ones
+				<-wait
+				continue
+			}
+			select {
+			case <-wait:
+			case <-ctx.Done():
+			}
+		}
+	}
+	// done of task, tasks after it may be ready
+	done := func(task *PipeTask) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, t := range next[task.Name()] {
+			pending[t.Name()]--
+			if pending[t.Name()] == 0 {
+				ready = append(ready, t)
+			}
+		}
+		close(changed)
+		changed = make(chan struct{})
+	}
+
+	workers := p.limiter.max
+	if workers > len(p.tasks) {
+		workers = len(p.tasks)
+	}
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for task, ok := take(); ok; task, ok = take() {
+				p.wg.Add(1)
+				p.taskRun(ctx, task, param, true)
+				done(task)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// error of tasks, nil if none of them failed
+func (p *pipeline) error() error {
+	if p.err == nil || len(p.err.Errors()) == 0 {
+		return nil
+	}
 	return p.err
 }
 
@@ -183,17 +331,34 @@ func (p *pipeline) taskRun(ctx context.Context, task *PipeTask, param Parameter,
 	}
 
 	// register close before run
-	p.closeChan <- task.Close
-
-	// run & wrap cause
-	err = task.Run(ctx, param)
-	if err != nil {
-		if p.err == nil {
-			p.err = NewError(Unknown, err.Error())
-		} else {
-			p.err.Wrap(err)
+	p.closeChan <- task
+
+	// isolated param & results of runAfter tasks
+	if p.isolated {
+		param = NewScope(param)
+	}
+	if len(task.runAfter) != 0 {
+		upstream := make(map[string]Parameter, len(task.runAfter))
+		for _, pre := range task.runAfter {
+			if result, ok := p.results.Get(pre).(Parameter); ok && result != nil {
+				upstream[pre] = result
+			}
 		}
+		param = &upstreamParam{Parameter: param, upstream: upstream}
+	}
+
+	// run & append error
+	err = p.limiter.acquire(ctx, task)
+	if err == nil {
+		clock := ClockOf(ctx)
+		start := clock.Now()
+		task.start.Store(&taskStart{clock: clock, at: start})
+		err = task.Run(ctx, param)
+		task.duration.Store(int64(clock.Since(start)))
+		task.start.Store(nil)
+		p.limiter.release(task)
 	}
+	p.err.Append(task.Name(), err)
 
 	// result
 	p.results.Set(task.Name(), task.Result())
@@ -214,18 +379,19 @@ func (p *pipeline) Result() Parameter {
 func (p *pipeline) Error() string {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
-	if p.err == nil {
-		return ""
+	if err := p.error(); err != nil {
+		return err.Error()
 	}
-	return p.err.Error()
+	return ""
 }
 
 // Close resource of Pipeline
-func (p *pipeline) Close() (err error) {
+func (p *pipeline) Close() error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	var (
-		closeSlice = make([]func() error, 0, len(p.tasks)+2)
+		closeSlice = make([]*PipeTask, 0, len(p.tasks)+2)
+		errs       = NewMultiError(Unknown)
 	)
 
 	if p.state == Running {
@@ -239,26 +405,27 @@ func (p *pipeline) Close() (err error) {
 	// close chan before for range
 	close(p.closeChan)
 	if p.postStart != nil {
-		closeSlice = append(closeSlice, p.postStart.Close)
+		closeSlice = append(closeSlice, p.postStart)
 	}
 
-	for closeFn := range p.closeChan {
-		closeSlice = append(closeSlice, closeFn)
+	for task := range p.closeChan {
+		closeSlice = append(closeSlice, task)
 	}
 
 	if p.preStop != nil {
-		closeSlice = append(closeSlice, p.preStop.Close)
+		closeSlice = append(closeSlice, p.preStop)
 	}
 
-	// close functions are called in reverse order
+	// tasks are closed in reverse order
 	for i := len(closeSlice) - 1; i >= 0; i-- {
-		if e := closeSlice[i](); e != nil {
-			err = NewErrorWrapped(e.Error(), err)
-		}
+		errs.Append(closeSlice[i].Name(), closeSlice[i].Close())
 	}
 
 	p.state = Closed
-	return
+	if len(errs.Errors()) == 0 {
+		return nil
+	}
+	return errs
 }
 
 // State of pipeline
@@ -284,3 +451,154 @@ func SetPreStopTask(t *PipeTask) PipelineOption {
 		p.preStop = t
 	}
 }
+
+// SetIsolated of pipeline, each task runs with its own scope of param
+// so parallel tasks can't race on the shared one
+func SetIsolated(isolated bool) PipelineOption {
+	return func(p *pipeline) {
+		p.isolated = isolated
+	}
+}
+
+// SetPipelineClock of pipeline, which is passed to its tasks by context, see ClockOf
+func SetPipelineClock(c Clock) PipelineOption {
+	return func(p *pipeline) {
+		p.clock = c
+	}
+}
+
+// SetMaxParallel of pipeline, tasks are run by a pool of max workers
+// & sum of weights of running tasks won't exceed max
+func SetMaxParallel(max int) PipelineOption {
+	return func(p *pipeline) {
+		if p.limiter == nil {
+			p.limiter = newLimiter()
+		}
+		p.limiter.max = max
+	}
+}
+
+// SetGroupLimit of pipeline, at most limit tasks of group run at the same time
+func SetGroupLimit(group string, limit int) PipelineOption {
+	return func(p *pipeline) {
+		if p.limiter == nil {
+			p.limiter = newLimiter()
+		}
+		p.limiter.limits[group] = limit
+	}
+}
+
+// limiter bounds running tasks of pipeline by weights & groups
+// tasks acquire slots after their runAfter tasks done, so no deadlock between dependencies
+type limiter struct {
+	mu sync.Mutex
+	// released is closed & renewed when slots released
+	released chan struct{}
+
+	max    int
+	used   int
+	limits map[string]int
+	groups map[string]int
+	// held slots of tasks by tryAcquire
+	held map[*PipeTask]bool
+}
+
+func newLimiter() *limiter {
+	return &limiter{
+		released: make(chan struct{}),
+		limits:   make(map[string]int),
+		groups:   make(map[string]int),
+		held:     make(map[*PipeTask]bool),
+	}
+}
+
+// weight of task, task heavier than max runs alone
+func (l *limiter) weight(task *PipeTask) int {
+	w := task.weight
+	if w <= 0 {
+		w = 1
+	}
+	if l.max > 0 && w > l.max {
+		w = l.max
+	}
+	return w
+}
+
+// acquire slots of task, waiting until released or ctx done
+func (l *limiter) acquire(ctx context.Context, task *PipeTask) error {
+	if l == nil {
+		return nil
+	}
+	for {
+		l.mu.Lock()
+		// held by tryAcquire before
+		if l.held[task] {
+			l.mu.Unlock()
+			return nil
+		}
+		if ctx.Err() != nil {
+			l.mu.Unlock()
+			return canceledError(ctx, "pipeline: context canceled before task %q runs", task.Name())
+		}
+		if l.hold(task) {
+			l.mu.Unlock()
+			return nil
+		}
+		released := l.released
+		l.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+		}
+	}
+}
+
+// tryAcquire slots of task without waiting
+func (l *limiter) tryAcquire(task *PipeTask) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.hold(task)
+}
+
+// hold slots of task if available with lock held, until released
+func (l *limiter) hold(task *PipeTask) bool {
+	w := l.weight(task)
+	if !l.available(task, w) {
+		return false
+	}
+	l.used += w
+	for _, g := range task.groups {
+		l.groups[g]++
+	}
+	l.held[task] = true
+	return true
+}
+
+func (l *limiter) available(task *PipeTask, w int) bool {
+	if l.max > 0 && l.used+w > l.max {
+		return false
+	}
+	for _, g := range task.groups {
+		if limit := l.limits[g]; limit > 0 && l.groups[g] >= limit {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *limiter) release(task *PipeTask) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.used -= l.weight(task)
+	for _, g := range task.groups {
+		l.groups[g]--
+	}
+	delete(l.held, task)
+	close(l.released)
+	l.released = make(chan struct{})
+}
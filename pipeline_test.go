@@ -17,7 +17,10 @@ package tao
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -109,7 +112,9 @@ func TestNewPipeline(t *testing.T) {
 
 		ctx, cancel := context.WithCancel(context.Background())
 		cancel()
-		assert.Equal(t, ContextCanceled, pipeA.Run(ctx, NewParameter()).(ErrorTao).Code())
+		err := pipeA.Run(ctx, NewParameter())
+		assert.Equal(t, ContextCanceled, err.(ErrorTao).Code())
+		assert.True(t, errors.Is(err, context.Canceled))
 
 		input := NewParameter()
 		input.Set("tao", "useful")
@@ -162,3 +167,118 @@ func TestNewPipeline(t *testing.T) {
 		assert.Equal(t, Closed, pipe.State())
 	})
 }
+
+func TestPipelineLimit(t *testing.T) {
+	var running, maxRunning, dbRunning, maxDBRunning int32
+	peak := func(cur int32, max *int32) {
+		for {
+			old := atomic.LoadInt32(max)
+			if cur <= old || atomic.CompareAndSwapInt32(max, old, cur) {
+				return
+			}
+		}
+	}
+	newTask := func(name string, db bool) Task {
+		return NewTask(name, func(ctx context.Context, param Parameter) (Parameter, error) {
+			peak(atomic.AddInt32(&running, 1), &maxRunning)
+			defer atomic.AddInt32(&running, -1)
+			if db {
+				peak(atomic.AddInt32(&dbRunning, 1), &maxDBRunning)
+				defer atomic.AddInt32(&dbRunning, -1)
+			}
+			time.Sleep(10 * time.Millisecond)
+			return param, nil
+		})
+	}
+
+	p := NewPipeline("limit", SetMaxParallel(3), SetGroupLimit("db", 1))
+	for i := 0; i < 4; i++ {
+		assert.Nil(t, p.Register(NewPipeTask(newTask(fmt.Sprintf("db%d", i), true)).WithGroups("db")))
+		assert.Nil(t, p.Register(NewPipeTask(newTask(fmt.Sprintf("task%d", i), false))))
+	}
+	assert.Nil(t, p.Register(NewPipeTask(newTask("heavy", false), "db0", "task0").WithWeight(5)))
+
+	assert.Nil(t, p.Run(context.Background(), nil))
+	assert.Equal(t, int32(3), maxRunning)
+	assert.Equal(t, int32(1), maxDBRunning)
+	assert.Equal(t, int32(0), running)
+}
+
+func TestPipelineLimitCanceled(t *testing.T) {
+	var ran int32
+	started := make(chan struct{}, 3)
+	p := NewPipeline("canceled", SetMaxParallel(1))
+	for _, name := range []string{"a", "b", "c"} {
+		assert.Nil(t, p.Register(NewPipeTask(NewTask(name, func(ctx context.Context, param Parameter) (Parameter, error) {
+			atomic.AddInt32(&ran, 1)
+			started <- struct{}{}
+			<-ctx.Done()
+			return param, nil
+		}))))
+	}
+
+	// tasks waiting for the slot fail once canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-started
+		cancel()
+	}()
+	err := p.Run(ctx, nil)
+	assert.True(t, HasCode(err, ContextCanceled))
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Len(t, err.(MultiError).Errors(), 2)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&ran))
+	assert.Nil(t, p.Close())
+}
+
+func TestPipelineCanceled(t *testing.T) {
+	for name, options := range map[string][]PipelineOption{
+		"Unlimited": nil,
+		"Limited":   {SetMaxParallel(1)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			p := NewPipeline("canceled", options...)
+			assert.Nil(t, p.Register(NewPipeTask(NewTask("cancel", func(ctx context.Context, param Parameter) (Parameter, error) {
+				cancel()
+				return param, nil
+			}))))
+			assert.Nil(t, p.Register(NewPipeTask(NewTask("next", func(ctx context.Context, param Parameter) (Parameter, error) {
+				return param, nil
+			}), "cancel")))
+
+			err := p.Run(ctx, nil)
+			assert.True(t, HasCode(err, ContextCanceled))
+			assert.True(t, errors.Is(err, context.Canceled))
+			assert.Nil(t, p.Close())
+		})
+	}
+}
+
+func TestPipelineUpstream(t *testing.T) {
+	p := NewPipeline("upstream", SetIsolated(true))
+	assert.Nil(t, p.Register(NewPipeTask(NewTask("producer", func(ctx context.Context, param Parameter) (Parameter, error) {
+		param.Set("addr", "127.0.0.1:8080")
+		return param, nil
+	}))))
+	assert.Nil(t, p.Register(NewPipeTask(NewTask("consumer", func(ctx context.Context, param Parameter) (Parameter, error) {
+		assert.Nil(t, param.Get("addr"))
+		assert.Nil(t, Upstream(param, "unknown"))
+		assert.Equal(t, "127.0.0.1:8080", Upstream(param, "producer").Get("addr"))
+		assert.Equal(t, Upstream(param, "producer"), param.Get(UpstreamPrefix+"producer"))
+		param.Set("consumed", true)
+		return param, nil
+	}), "producer")))
+
+	input := NewParameter()
+	assert.Nil(t, p.Run(context.Background(), input))
+	assert.Nil(t, input.Get("addr"))
+	assert.Nil(t, input.Get("consumed"))
+
+	consumer := p.Result().Get("consumer").(Parameter)
+	assert.Equal(t, true, consumer.Get("consumed"))
+	assert.Equal(t, `{"consumed":true}`, consumer.String())
+}
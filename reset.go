@@ -0,0 +1,79 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"io"
+	"text/template"
+
+	"github.com/taouniverse/tao/internal/hook"
+)
+
+func init() {
+	hook.Reset = reset
+	hook.CaptureLogs = func(w io.Writer) {
+		logCapture = w
+	}
+}
+
+// reset global state of tao as if the process just started without config files
+// the universe over is closed, so are writers & samplers of logs opened by taoInit
+func reset() {
+	stopShutdown()
+
+	// universe
+	tao.mu.Lock()
+	for _, p := range []Pipeline{tao.Pipeline, tao.universe} {
+		if p.State() == Over {
+			_ = p.Close()
+		}
+	}
+	tao.Pipeline = NewPipeline(ConfigKey)
+	tao.universe = NewPipeline("universe")
+	tao.units = make(map[string]struct{})
+	tao.mu.Unlock()
+
+	// config
+	configInterfaceMap = make(map[string]interface{})
+	configMap = make(map[string]Config)
+	configPath = ""
+	once = make(chan struct{}, 1)
+	t = new(taoConfig)
+
+	// log
+	if l, ok := globalLogger.loggerMap()[ConfigKey].(*logger); ok && l.sampler != nil {
+		l.sampler.close()
+	}
+	for i := len(logClosers) - 1; i >= 0; i-- {
+		_ = logClosers[i].Close()
+	}
+	logClosers = nil
+	globalLogger.mu.Lock()
+	globalLogger.loggers.Store(map[string]Logger{})
+	globalLogger.writers.Store(map[string]io.Writer{})
+	globalLogger.defaultKey.Store(ConfigKey)
+	globalLogger.mu.Unlock()
+	logLevelsMu.Lock()
+	logLevels.Store(map[string]LogLevel{})
+	logLevelsMu.Unlock()
+
+	// error & clock
+	SetErrorStack(false)
+	errorCodes = newErrorCodeRegistry()
+	errorCatalogs.Lock()
+	errorCatalogs.m = make(map[string]map[string]*template.Template)
+	errorCatalogs.Unlock()
+	SetClock(nil)
+}
@@ -0,0 +1,34 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tao
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/taouniverse/tao/internal/hook"
+)
+
+func TestResetHook(t *testing.T) {
+	// reset is tested by taotest, which breaks universe of this package
+	assert.NotNil(t, hook.Reset)
+
+	buf := new(bytes.Buffer)
+	hook.CaptureLogs(buf)
+	assert.Equal(t, buf, logCapture)
+	hook.CaptureLogs(nil)
+	assert.Nil(t, logCapture)
+}
@@ -21,6 +21,7 @@ import (
 	"os"
 	"os/signal"
 	"reflect"
+	"sort"
 	"sync"
 	"syscall"
 )
@@ -31,12 +32,16 @@ type Universe struct {
 
 	Pipeline
 	universe Pipeline
+
+	mu    sync.Mutex
+	units map[string]struct{}
 }
 
 // The Tao produced One; One produced Two; Two produced Three; Three produced All things.
 var tao = &Universe{
 	Pipeline: NewPipeline(ConfigKey),
 	universe: NewPipeline("universe"),
+	units:    make(map[string]struct{}),
 }
 
 // Add of tao
@@ -45,34 +50,37 @@ var Add = tao.Add
 // Done of tao
 var Done = tao.Done
 
-// Run tao
-func Run(ctx context.Context, param Parameter) (err error) {
+// Graph of tao's tasks without running them, see PipelineGrapher.Graph
+// tasks of units are registered first as Plan does
+func Graph(ctx context.Context, format GraphFormat, annotate bool) (string, error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
-	if param == nil {
-		param = NewParameter()
+	err := prepare(ctx)
+	if err != nil {
+		return "", err
+	}
+	g, ok := tao.Pipeline.(PipelineGrapher)
+	if !ok {
+		return "", NewError(ParamInvalid, "tao: %T can't be graphed", tao.Pipeline)
 	}
+	return g.Graph(format, annotate), nil
+}
 
-	if len(once) == 0 {
-		// refer to defaultConfigs in init.go to get some help
-		return NewError(UniverseNotInit, "none of %+v existed", defaultConfigs)
+// Run tao
+func Run(ctx context.Context, param Parameter) (err error) {
+	if ctx == nil {
+		ctx = context.Background()
 	}
 
-	// non-block check
-	select {
-	case <-ctx.Done():
-		return NewError(ContextCanceled, "tao: context has been canceled")
-	default:
+	if param == nil {
+		param = NewParameter()
 	}
 
-	// tasks register
-	for _, c := range configMap {
-		err = tao.Register(NewPipeTask(c.ToTask(), c.RunAfter()...))
-		if err != nil {
-			return NewErrorWrapped("tao: fail to register unit task", err)
-		}
+	err = prepare(ctx)
+	if err != nil {
+		return err
 	}
 
 	// debug print
@@ -96,9 +104,79 @@ func Run(ctx context.Context, param Parameter) (err error) {
 
 	// tao wait
 	tao.Wait()
+	_ = FlushLogs()
 	return
 }
 
+// Plan of tao without running any task
+// config loading, registration & dependency graph of units are all checked,
+// then the execution layers are returned, tasks in the same layer run in parallel
+func Plan(ctx context.Context) ([][]string, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	err := prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// config errors of units
+	if e := tao.universe.Error(); e != "" {
+		return nil, NewError(UniverseNotInit, "tao: fail to init universe%s", errSplit+e)
+	}
+
+	g, ok := tao.Pipeline.(PipelineGrapher)
+	if !ok {
+		return nil, NewError(ParamInvalid, "tao: %T can't be planned", tao.Pipeline)
+	}
+	layers, err := g.Layers()
+	if err != nil {
+		return nil, NewErrorWrapped("tao: fail to plan", err)
+	}
+	return layers, nil
+}
+
+// prepare universe & tasks of units before tao run
+func prepare(ctx context.Context) (err error) {
+	if len(once) == 0 {
+		// refer to defaultConfigs in init.go to get some help
+		return NewError(UniverseNotInit, "none of %+v existed", defaultConfigs)
+	}
+
+	// non-block check
+	select {
+	case <-ctx.Done():
+		return canceledError(ctx, "tao: context has been canceled")
+	default:
+	}
+
+	// error codes registered by units
+	if err = errorCodes.check(); err != nil {
+		return err
+	}
+
+	// tasks register, units registered before are skipped
+	tao.mu.Lock()
+	defer tao.mu.Unlock()
+	keys := make([]string, 0, len(configMap))
+	for key := range configMap {
+		if _, ok := tao.units[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		c := configMap[key]
+		err = tao.Register(NewPipeTask(c.ToTask(), c.RunAfter()...))
+		if err != nil {
+			return NewErrorWrapped("tao: fail to register unit task", err)
+		}
+		tao.units[key] = struct{}{}
+	}
+	return nil
+}
+
 // Register unit to tao universe
 func Register(configKey string, config Config, setup func() error) error {
 	rv := reflect.ValueOf(config)
@@ -152,7 +230,7 @@ func Register(configKey string, config Config, setup func() error) error {
 		return tao.universe.Register(NewPipeTask(NewTask(configKey, func(ctx context.Context, param Parameter) (Parameter, error) {
 			select {
 			case <-ctx.Done():
-				return param, NewError(ContextCanceled, "universe: fail to init %q", configKey)
+				return param, canceledError(ctx, "universe: fail to init %q", configKey)
 			default:
 				return param, unitSetup()
 			}
@@ -160,19 +238,37 @@ func Register(configKey string, config Config, setup func() error) error {
 	}
 }
 
+// stopShutdown stops the signal handling of last gracefulShutdown
+var stopShutdown = func() {}
+
 func gracefulShutdown() {
+	stopShutdown()
+
 	sc := make(chan os.Signal, 1)
+	stop := make(chan struct{})
 	signal.Notify(sc)
+	stopShutdown = func() {
+		signal.Stop(sc)
+		close(stop)
+		stopShutdown = func() {}
+	}
 	go func() {
 		for {
-			sig := <-sc
+			var sig os.Signal
+			select {
+			case sig = <-sc:
+			case <-stop:
+				return
+			}
 			if _, ok := map[os.Signal]struct{}{
 				syscall.SIGINT:  {},
 				syscall.SIGQUIT: {},
 				syscall.SIGTERM: {},
 			}[sig]; ok {
 				Debugf("got exiting signal now: %v", sig)
-				if err := tao.Close(); err != nil {
+				err := tao.Close()
+				_ = FlushLogs()
+				if err != nil {
 					os.Exit(1)
 				} else {
 					os.Exit(0)
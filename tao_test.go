@@ -44,6 +44,20 @@ func TestRun(t *testing.T) {
 	err := Run(cancel, nil)
 	assert.NotNil(t, err)
 
+	_, err = Plan(cancel)
+	assert.NotNil(t, err)
+
+	_, err = Graph(cancel, DOT, false)
+	assert.NotNil(t, err)
+
+	dot, err := Graph(nil, DOT, false)
+	assert.Nil(t, err)
+	assert.Contains(t, dot, `"`+printConfigKey+`"`)
+
+	layers, err := Plan(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{preConfigKey, printConfigKey}}, layers)
+
 	Add(1)
 	Done()
 	err = Run(nil, nil)
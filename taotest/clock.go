@@ -0,0 +1,189 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taotest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/taouniverse/tao"
+)
+
+// FakeEpoch of FakeClock by default
+var FakeEpoch = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var _ tao.Clock = (*FakeClock)(nil)
+
+// FakeClock of tao, whose time moves only by Advance
+// timers, tickers & sleepers fire in order of their deadlines when time moves
+type FakeClock struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	now     time.Time
+	waiters []*fakeTimer
+}
+
+// NewFakeClock at now, FakeEpoch if now is zero
+func NewFakeClock(now time.Time) *FakeClock {
+	if now.IsZero() {
+		now = FakeEpoch
+	}
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now of fake time
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Since t in fake time
+func (c *FakeClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Sleep until time advanced by d
+func (c *FakeClock) Sleep(d time.Duration) {
+	<-c.NewTimer(d).C()
+}
+
+// NewTimer fires once after d
+func (c *FakeClock) NewTimer(d time.Duration) tao.Timer {
+	t := &fakeTimer{clock: c, c: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// NewTicker fires every d
+func (c *FakeClock) NewTicker(d time.Duration) tao.Ticker {
+	if d <= 0 {
+		panic("taotest: non-positive interval for NewTicker")
+	}
+	t := &fakeTimer{clock: c, c: make(chan time.Time, 1), period: d}
+	t.Reset(d)
+	return fakeTicker{t}
+}
+
+// Advance time by d, firing timers, tickers & sleepers whose deadlines are reached
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	end := c.now.Add(d)
+	for len(c.waiters) != 0 && !c.waiters[0].when.After(end) {
+		t := c.waiters[0]
+		c.now = t.when
+		select {
+		case t.c <- c.now:
+		default:
+			// ticks are dropped like time.Ticker
+		}
+		if t.period > 0 {
+			t.when = t.when.Add(t.period)
+			c.sort()
+		} else {
+			c.remove(t)
+		}
+	}
+	c.now = end
+}
+
+// BlockUntil n timers, tickers & sleepers are waiting, so Advance fires them in goroutines deterministically
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
+
+// Waiters of timers, tickers & sleepers
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// sort waiters by deadlines with lock held
+func (c *FakeClock) sort() {
+	sort.SliceStable(c.waiters, func(i, j int) bool {
+		return c.waiters[i].when.Before(c.waiters[j].when)
+	})
+}
+
+// remove t from waiters with lock held, whether it's waiting is returned
+func (c *FakeClock) remove(t *fakeTimer) bool {
+	for i, w := range c.waiters {
+		if w == t {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+var _ tao.Timer = (*fakeTimer)(nil)
+var _ tao.Ticker = fakeTicker{}
+
+// fakeTimer of FakeClock, which is a ticker if period is positive
+type fakeTimer struct {
+	clock  *FakeClock
+	c      chan time.Time
+	when   time.Time
+	period time.Duration
+}
+
+// C of timer
+func (t *fakeTimer) C() <-chan time.Time { return t.c }
+
+// Stop timer, whether it's waiting is returned
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.remove(t)
+}
+
+// Reset timer to fire after d, whether it's waiting is returned
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	waiting := c.remove(t)
+	if t.period > 0 {
+		t.period = d
+	}
+	t.when = c.now.Add(d)
+	c.waiters = append(c.waiters, t)
+	c.sort()
+	c.cond.Broadcast()
+	return waiting
+}
+
+// fakeTicker of FakeClock
+type fakeTicker struct {
+	*fakeTimer
+}
+
+// Stop ticker
+func (t fakeTicker) Stop() { t.fakeTimer.Stop() }
+
+// Reset ticker to fire every d
+func (t fakeTicker) Reset(d time.Duration) { t.fakeTimer.Reset(d) }
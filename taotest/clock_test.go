@@ -0,0 +1,133 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taotest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/taouniverse/tao"
+)
+
+func TestFakeClock(t *testing.T) {
+	t.Run("Timer", func(t *testing.T) {
+		c := NewFakeClock(time.Time{})
+		assert.Equal(t, FakeEpoch, c.Now())
+
+		t2 := c.NewTimer(2 * time.Second)
+		t1 := c.NewTimer(time.Second)
+		assert.Equal(t, 2, c.Waiters())
+
+		c.Advance(time.Second)
+		assert.Equal(t, FakeEpoch.Add(time.Second), <-t1.C())
+		assert.Len(t, t2.C(), 0)
+		assert.False(t, t1.Stop())
+
+		assert.True(t, t2.Reset(time.Second))
+		c.Advance(999 * time.Millisecond)
+		assert.Len(t, t2.C(), 0)
+		c.Advance(time.Millisecond)
+		assert.Equal(t, FakeEpoch.Add(2*time.Second), <-t2.C())
+		assert.Equal(t, 2*time.Second, c.Since(FakeEpoch))
+
+		t3 := c.NewTimer(time.Second)
+		assert.True(t, t3.Stop())
+		c.Advance(time.Hour)
+		assert.Len(t, t3.C(), 0)
+		assert.Equal(t, 0, c.Waiters())
+	})
+
+	t.Run("Ticker", func(t *testing.T) {
+		c := NewFakeClock(time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC))
+		ticker := c.NewTicker(time.Minute)
+		c.Advance(time.Minute)
+		assert.Equal(t, c.Now(), <-ticker.C())
+
+		// ticks are dropped if not received
+		c.Advance(3 * time.Minute)
+		assert.Len(t, ticker.C(), 1)
+		assert.Equal(t, c.Now().Add(-2*time.Minute), <-ticker.C())
+
+		ticker.Reset(time.Hour)
+		c.Advance(time.Minute)
+		assert.Len(t, ticker.C(), 0)
+		ticker.Stop()
+		assert.Equal(t, 0, c.Waiters())
+
+		assert.Panics(t, func() {
+			c.NewTicker(0)
+		})
+	})
+
+	t.Run("Sleep", func(t *testing.T) {
+		c := NewFakeClock(time.Time{})
+		wg := sync.WaitGroup{}
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c.Sleep(time.Second)
+			}()
+		}
+		c.BlockUntil(3)
+		c.Advance(time.Second)
+		wg.Wait()
+	})
+
+	t.Run("WithTimeout", func(t *testing.T) {
+		c := NewFakeClock(time.Time{})
+		ctx, cancel := tao.WithTimeout(c, context.Background(), time.Minute)
+		defer cancel()
+		child, cancelChild := context.WithCancel(ctx)
+		defer cancelChild()
+
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.Equal(t, FakeEpoch.Add(time.Minute), deadline)
+
+		c.BlockUntil(1)
+		c.Advance(59 * time.Second)
+		assert.Nil(t, ctx.Err())
+		c.Advance(time.Second)
+		<-child.Done()
+		assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+		assert.Equal(t, context.DeadlineExceeded, child.Err())
+
+		// canceled by parent
+		parent, cancelParent := context.WithCancel(context.Background())
+		ctx, cancel = tao.WithTimeout(c, parent, time.Minute)
+		defer cancel()
+		cancelParent()
+		<-ctx.Done()
+		assert.Equal(t, context.Canceled, ctx.Err())
+		assert.Eventually(t, func() bool {
+			return c.Waiters() == 0
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("Universe", func(t *testing.T) {
+		u := New(t, "tao:\n  log:\n    flag: std\n  banner:\n    hide: true\n")
+		assert.Equal(t, u.Clock(), tao.GetClock())
+		assert.Nil(t, u.Init())
+
+		u.Clock().Advance(90 * time.Minute)
+		tao.Info("fake")
+		assert.Equal(t, []string{"2022/01/01 01:30:00 [I] fake"}, u.Logs().Lines())
+	})
+	assert.Equal(t, time.Now().Year(), tao.GetClock().Now().Year())
+}
@@ -0,0 +1,138 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taotest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/taouniverse/tao"
+)
+
+// update golden files instead of comparing, e.g. go test ./... -taotest.update
+// the flag is namespaced to not conflict with -update of packages importing taotest
+var update = flag.Bool("taotest.update", false, "update golden files of taotest")
+
+// GoldenDir of golden files, relative to the directory of package tested
+var GoldenDir = "testdata"
+
+// normalizers of Normalize in order
+var normalizers = []struct {
+	pattern *regexp.Regexp
+	repl    string
+}{
+	// 2022-01-02T15:04:05.000000+08:00
+	{regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`), "<time>"},
+	// 2022/01/02 15:04:05.000000
+	{regexp.MustCompile(`\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}(\.\d+)?`), "<time>"},
+	// 15:04:05.000000 without date
+	{regexp.MustCompile(`\b\d{2}:\d{2}:\d{2}(\.\d+)?\b`), "<time>"},
+	// +   1.234s of pretty log in relative time
+	{regexp.MustCompile(`\+\s*\d+\.\d{3}s`), "<time>"},
+	// line of caller
+	{regexp.MustCompile(`\.go:\d+`), ".go:<line>"},
+}
+
+// Normalize timestamps, lines of callers, working & temporary directories in s,
+// so the output compared with golden files is stable
+func Normalize(s string) string {
+	if wd, err := os.Getwd(); err == nil {
+		s = strings.ReplaceAll(s, wd, "<wd>")
+	}
+	s = strings.ReplaceAll(s, os.TempDir(), "<tmp>")
+	for _, n := range normalizers {
+		s = n.pattern.ReplaceAllString(s, n.repl)
+	}
+	return s
+}
+
+// SortLines of s, so lines written by goroutines in any order are stable
+func SortLines(s string) string {
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	sort.Strings(lines)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// StableJSON of v indented with sorted keys, Parameter & nested ones included
+func StableJSON(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, tao.NewErrorWrapped("taotest: fail to marshal", err)
+	}
+	// keys of maps are sorted by json.Marshal
+	var generic interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(&generic); err != nil {
+		return nil, tao.NewErrorWrapped("taotest: fail to unmarshal", err)
+	}
+	data, err = json.MarshalIndent(generic, "", "  ")
+	if err != nil {
+		return nil, tao.NewErrorWrapped("taotest: fail to marshal", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// AssertGolden got equals to content of golden file GoldenDir/name.golden
+// the golden file is written instead if -taotest.update is set
+func AssertGolden(tb testing.TB, name string, got []byte) bool {
+	tb.Helper()
+	path := filepath.Join(GoldenDir, name+".golden")
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			tb.Fatalf("taotest: fail to create dir of golden file: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			tb.Fatalf("taotest: fail to update golden file: %v", err)
+		}
+		return true
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("taotest: fail to read golden file, run with -taotest.update to create it: %v", err)
+	}
+	return assert.Equal(tb, string(want), string(got), "taotest: %s differs from golden file, run with -taotest.update if expected", name)
+}
+
+// AssertResultGolden of result in stable JSON, e.g. result of tao.Pipeline
+func AssertResultGolden(tb testing.TB, name string, result tao.Parameter) bool {
+	tb.Helper()
+	got, err := StableJSON(result)
+	if err != nil {
+		tb.Fatalf("%v", err)
+	}
+	return AssertGolden(tb, name, got)
+}
+
+// AssertLogsGolden of logs captured by universe, which are normalized first
+// lines are sorted as well if sorted, for logs written by tasks in parallel
+func (u *Universe) AssertLogsGolden(name string, sorted bool) bool {
+	u.tb.Helper()
+	logs := Normalize(u.logs.String())
+	if sorted {
+		logs = SortLines(logs)
+	}
+	return AssertGolden(u.tb, name, []byte(logs))
+}
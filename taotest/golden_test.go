@@ -0,0 +1,129 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taotest
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/taouniverse/tao"
+)
+
+// setUpdate of golden files until test finishes
+func setUpdate(t *testing.T, u bool) {
+	old := *update
+	*update = u
+	t.Cleanup(func() {
+		*update = old
+	})
+}
+
+func TestUpdateFlag(t *testing.T) {
+	assert.NotNil(t, flag.Lookup("taotest.update"))
+	// -update is left to packages importing taotest
+	assert.Nil(t, flag.Lookup("update"))
+}
+
+func TestNormalize(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.Nil(t, err)
+
+	assert.Equal(t, "<time> log.go:<line>: [I] hi", Normalize("2022/01/02 15:04:05 log.go:42: [I] hi"))
+	assert.Equal(t, "<time> hi", Normalize("2022/01/02 15:04:05.123456 hi"))
+	assert.Equal(t, `{"time":"<time>"}`, Normalize(`{"time":"2022-01-02T15:04:05.123+08:00"}`))
+	assert.Equal(t, "<time> <time> INF", Normalize("15:04:05.000 +     1.234s INF"))
+	assert.Equal(t, "open <wd>/conf/config.yaml", Normalize("open "+filepath.Join(wd, "conf", "config.yaml")))
+	assert.Equal(t, "<tmp>/test.log", Normalize(filepath.Join(os.TempDir(), "test.log")))
+}
+
+func TestSortLines(t *testing.T) {
+	assert.Equal(t, "a\nb\nc\n", SortLines("c\na\nb\n"))
+	assert.Equal(t, "a\nb\n", SortLines("b\na"))
+}
+
+func TestStableJSON(t *testing.T) {
+	param := tao.NewParameter()
+	param.Set("b", 1)
+	param.Set("a", "x")
+	nested := tao.NewParameter()
+	nested.Set("z", true)
+	nested.Set("y", []int{1, 2})
+	param.Set("c", nested)
+
+	data, err := StableJSON(param)
+	assert.Nil(t, err)
+	assert.Equal(t, `{
+  "a": "x",
+  "b": 1,
+  "c": {
+    "y": [
+      1,
+      2
+    ],
+    "z": true
+  }
+}
+`, string(data))
+
+	_, err = StableJSON(func() {})
+	assert.NotNil(t, err)
+}
+
+func TestAssertGolden(t *testing.T) {
+	old := GoldenDir
+	GoldenDir = t.TempDir()
+	defer func() {
+		GoldenDir = old
+	}()
+
+	setUpdate(t, true)
+	assert.True(t, AssertGolden(t, "dir/name", []byte("golden\n")))
+	data, err := os.ReadFile(filepath.Join(GoldenDir, "dir", "name.golden"))
+	assert.Nil(t, err)
+	assert.Equal(t, "golden\n", string(data))
+
+	setUpdate(t, false)
+	assert.True(t, AssertGolden(t, "dir/name", []byte("golden\n")))
+	r := &recorder{TB: t}
+	assert.False(t, AssertGolden(r, "dir/name", []byte("silver\n")))
+	assert.Len(t, r.errors, 1)
+}
+
+func TestAssertLogsGolden(t *testing.T) {
+	u := New(t, config)
+	u.Register("greet", new(greetConfig), nil)
+	assert.Nil(t, u.Run(context.Background(), nil))
+	u.AssertLogsGolden("universe_logs", false)
+}
+
+func TestAssertResultGolden(t *testing.T) {
+	New(t, "")
+	p := tao.NewPipeline("golden")
+	for _, name := range []string{"b", "a"} {
+		name := name
+		assert.Nil(t, p.Register(tao.NewPipeTask(tao.NewTask(name, func(ctx context.Context, param tao.Parameter) (tao.Parameter, error) {
+			result := tao.NewParameter()
+			result.Set("name", name)
+			result.Set("count", len(name))
+			return result, nil
+		}))))
+	}
+	assert.Nil(t, p.Run(context.Background(), nil))
+	AssertResultGolden(t, "pipeline_result", p.Result())
+}
@@ -0,0 +1,94 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taotest
+
+import (
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// leakTimeout to wait goroutines exiting
+var leakTimeout = 2 * time.Second
+
+// leakIgnored goroutines, which belong to other tests or the runtime
+var leakIgnored = []string{
+	"created by testing.",
+	"os/signal.",
+}
+
+// goroutines alive except the current one, id -> stack
+func goroutines() map[string]string {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	stacks := strings.Split(string(buf), "\n\n")
+	alive := make(map[string]string, len(stacks))
+	// the first one is current goroutine
+	for _, stack := range stacks[1:] {
+		id, _, _ := strings.Cut(strings.TrimPrefix(stack, "goroutine "), " ")
+		alive[id] = stack
+	}
+	return alive
+}
+
+// leaked goroutines started after snapshot
+func leaked(snapshot map[string]string) []string {
+	var stacks []string
+	for id, stack := range goroutines() {
+		if _, ok := snapshot[id]; ok || ignored(stack) {
+			continue
+		}
+		stacks = append(stacks, stack)
+	}
+	sort.Strings(stacks)
+	return stacks
+}
+
+// ignored stack or not
+func ignored(stack string) bool {
+	for _, s := range leakIgnored {
+		if strings.Contains(stack, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkLeaks of goroutines started after snapshot, which are waited for leakTimeout
+func checkLeaks(tb testing.TB, snapshot map[string]string) {
+	tb.Helper()
+	deadline := time.Now().Add(leakTimeout)
+	for {
+		stacks := leaked(snapshot)
+		if len(stacks) == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			tb.Errorf("taotest: %d goroutines leaked\n\n%s", len(stacks), strings.Join(stacks, "\n\n"))
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taotest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder of errors reported by checkLeaks
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, format)
+}
+
+func TestCheckLeaks(t *testing.T) {
+	old := leakTimeout
+	leakTimeout = 100 * time.Millisecond
+	defer func() {
+		leakTimeout = old
+	}()
+
+	snapshot := goroutines()
+	stop := make(chan struct{})
+	go func() {
+		<-stop
+	}()
+
+	r := &recorder{TB: t}
+	checkLeaks(r, snapshot)
+	assert.Len(t, r.errors, 1)
+	assert.True(t, strings.HasPrefix(r.errors[0], "taotest: %d goroutines leaked"))
+
+	close(stop)
+	r = &recorder{TB: t}
+	checkLeaks(r, snapshot)
+	assert.Len(t, r.errors, 0)
+}
@@ -0,0 +1,44 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taotest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogBuffer(t *testing.T) {
+	b := new(LogBuffer)
+	assert.Nil(t, b.Lines())
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _ = fmt.Fprintf(b, "line %d\n", i)
+		}(i)
+	}
+	wg.Wait()
+	assert.Len(t, b.Lines(), 10)
+	assert.True(t, b.Contains("line 9\n"))
+	assert.False(t, b.Contains("line 10"))
+
+	b.Reset()
+	assert.Equal(t, "", b.String())
+}
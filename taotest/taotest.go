@@ -0,0 +1,131 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package taotest provides isolated universes of tao to test units built on it
+//
+// Universes are serialized in process because tao has global state:
+// New blocks until the universe alive is closed, so tests calling t.Parallel
+// are hermetic but never run their universes concurrently.
+package taotest
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/taouniverse/tao"
+	"github.com/taouniverse/tao/internal/hook"
+)
+
+// Universe of tao isolated for a test
+type Universe struct {
+	tb     testing.TB
+	config []byte
+	logs   *LogBuffer
+	clock  *FakeClock
+	inited bool
+}
+
+// owner of the universe alive, whose test holds hook.Mu
+var owner struct {
+	sync.Mutex
+	name string
+}
+
+// New universe configured by yaml, e.g. "tao:\n  log:\n    level: info"
+// global state of tao is reset, config files are not read, logs are captured in memory
+// & clock of tao is replaced by a FakeClock at FakeEpoch,
+// the universe is closed with leak checks of goroutines when tb finishes.
+//
+// NOTE: universes are serialized, see package doc,
+// New fails tb if called in the test or subtests of another universe, which would deadlock
+func New(tb testing.TB, yaml string) *Universe {
+	tb.Helper()
+
+	if !hook.Mu.TryLock() {
+		owner.Lock()
+		name := owner.name
+		owner.Unlock()
+		if tb.Name() == name || strings.HasPrefix(tb.Name(), name+"/") {
+			tb.Fatalf("taotest: universe of %q is alive, New can't be called in it or its subtests", name)
+		}
+		hook.Mu.Lock()
+	}
+	owner.Lock()
+	owner.name = tb.Name()
+	owner.Unlock()
+	snapshot := goroutines()
+	hook.Reset()
+	u := &Universe{
+		tb:     tb,
+		config: []byte(yaml),
+		logs:   new(LogBuffer),
+		clock:  NewFakeClock(time.Time{}),
+	}
+	hook.CaptureLogs(u.logs)
+	tao.SetClock(u.clock)
+
+	tb.Cleanup(func() {
+		defer hook.Mu.Unlock()
+		defer func() {
+			owner.Lock()
+			owner.name = ""
+			owner.Unlock()
+		}()
+		hook.Reset()
+		hook.CaptureLogs(nil)
+		checkLeaks(tb, snapshot)
+	})
+	return u
+}
+
+// Register unit to universe before Init, see tao.Register
+func (u *Universe) Register(configKey string, config tao.Config, setup func() error) {
+	u.tb.Helper()
+	if err := tao.Register(configKey, config, setup); err != nil {
+		u.tb.Fatalf("taotest: fail to register %q: %v", configKey, err)
+	}
+}
+
+// Init universe by config, units registered are set up
+// it's called by Run if not called before
+func (u *Universe) Init() error {
+	if u.inited {
+		return tao.NewError(tao.DuplicateCall, "taotest: init twice")
+	}
+	u.inited = true
+	return tao.SetAllConfigBytes(u.config, tao.Yaml)
+}
+
+// Run tasks of units, see tao.Run
+func (u *Universe) Run(ctx context.Context, param tao.Parameter) error {
+	if !u.inited {
+		if err := u.Init(); err != nil {
+			return err
+		}
+	}
+	return tao.Run(ctx, param)
+}
+
+// Clock of universe, which moves only by Advance
+func (u *Universe) Clock() *FakeClock {
+	return u.clock
+}
+
+// Logs captured since New, including the banner
+func (u *Universe) Logs() *LogBuffer {
+	return u.logs
+}
@@ -0,0 +1,168 @@
+// Copyright 2022 huija
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/taouniverse/tao"
+)
+
+// greetConfig of a unit for test
+type greetConfig struct {
+	Word  string `json:"word"`
+	Times int    `json:"times"`
+	fail  bool
+}
+
+func (g *greetConfig) Name() string { return "greet" }
+
+func (g *greetConfig) ValidSelf() {
+	if g.Word == "" {
+		g.Word = "hello"
+	}
+	if g.Times <= 0 {
+		g.Times = 1
+	}
+}
+
+func (g *greetConfig) ToTask() tao.Task {
+	return tao.NewTask(g.Name(), func(ctx context.Context, param tao.Parameter) (tao.Parameter, error) {
+		for i := 0; i < g.Times; i++ {
+			tao.Infof("greet: %s", g.Word)
+		}
+		if g.fail {
+			return param, errors.New("greet: fail")
+		}
+		return param, nil
+	})
+}
+
+func (g *greetConfig) RunAfter() []string { return nil }
+
+const config = `
+tao:
+  log:
+    level: info
+  banner:
+    content: taotest
+greet:
+  word: hi
+  times: 2
+`
+
+func TestUniverse(t *testing.T) {
+	t.Run("Run", func(t *testing.T) {
+		u := New(t, config)
+		g := new(greetConfig)
+		var setup bool
+		u.Register("greet", g, func() error {
+			setup = true
+			return nil
+		})
+
+		assert.Nil(t, u.Run(context.Background(), nil))
+		assert.True(t, setup)
+		assert.Equal(t, "hi", g.Word)
+		assert.Equal(t, tao.INFO, tao.GetLogLevel("tao"))
+		assert.Equal(t, "taotest", u.Logs().Lines()[0])
+		assert.Len(t, u.Logs().Lines(), 3)
+		assert.True(t, u.Logs().Contains("greet: hi"))
+		assert.NotNil(t, u.Init())
+	})
+
+	t.Run("Isolated", func(t *testing.T) {
+		u := New(t, "")
+		g := &greetConfig{fail: true}
+		u.Register("greet", g, nil)
+		assert.Nil(t, u.Init())
+		assert.Equal(t, "hello", g.Word)
+		assert.Equal(t, tao.DEBUG, tao.GetLogLevel("tao"))
+
+		err := u.Run(context.Background(), nil)
+		assert.NotNil(t, err)
+		var taskErr *tao.TaskError
+		assert.True(t, errors.As(err, &taskErr))
+		assert.Equal(t, "greet", taskErr.Name)
+		assert.True(t, u.Logs().Contains("[D] config data"))
+	})
+
+	t.Run("Plan", func(t *testing.T) {
+		u := New(t, config)
+		u.Register("greet", new(greetConfig), func() error {
+			return errors.New("greet: fail to setup")
+		})
+		assert.NotNil(t, u.Init())
+
+		// init error ignored is reported by Plan
+		_, err := tao.Plan(context.Background())
+		assert.True(t, tao.HasCode(err, tao.UniverseNotInit))
+	})
+
+	t.Run("InitError", func(t *testing.T) {
+		u := New(t, "tao: [")
+		assert.NotNil(t, u.Run(context.Background(), nil))
+	})
+}
+
+// fatalRecorder of Fatalf, which stops the caller by panic
+type fatalRecorder struct {
+	testing.TB
+	fatal string
+}
+
+func (r *fatalRecorder) Helper() {}
+
+func (r *fatalRecorder) Fatalf(format string, args ...interface{}) {
+	r.fatal = fmt.Sprintf(format, args...)
+	panic(r)
+}
+
+func TestNested(t *testing.T) {
+	New(t, "")
+	t.Run("Sub", func(t *testing.T) {
+		r := &fatalRecorder{TB: t}
+		func() {
+			defer func() {
+				assert.Equal(t, r, recover())
+			}()
+			New(r, "")
+		}()
+		assert.Equal(t, `taotest: universe of "TestNested" is alive, New can't be called in it or its subtests`, r.fatal)
+	})
+}
+
+// TestParallel tests are hermetic, universes of them are serialized by New
+func TestParallel(t *testing.T) {
+	for _, word := range []string{"a", "b", "c"} {
+		word := word
+		t.Run(word, func(t *testing.T) {
+			t.Parallel()
+			u := New(t, "greet:\n  word: "+word)
+			u.Register("greet", new(greetConfig), nil)
+			assert.Nil(t, u.Run(context.Background(), nil))
+			assert.True(t, u.Logs().Contains("greet: "+word))
+			for _, other := range []string{"a", "b", "c"} {
+				if other != word {
+					assert.False(t, u.Logs().Contains("greet: "+other))
+				}
+			}
+		})
+	}
+}
@@ -16,6 +16,7 @@ package tao
 
 import (
 	"context"
+	"fmt"
 	"sync"
 )
 
@@ -33,6 +34,22 @@ const (
 	Closed
 )
 
+// String of TaskState
+func (s TaskState) String() string {
+	switch s {
+	case Runnable:
+		return "runnable"
+	case Running:
+		return "running"
+	case Over:
+		return "over"
+	case Closed:
+		return "closed"
+	default:
+		return fmt.Sprintf("tao.TaskState(%d)", s)
+	}
+}
+
 // TaskRun with param
 type TaskRun func(ctx context.Context, param Parameter) (Parameter, error)
 
@@ -58,6 +75,7 @@ type task struct {
 	closeFun  func() error
 	postStart TaskRun
 	preStop   TaskRun
+	clock     Clock
 
 	result Parameter
 	err    error
@@ -111,10 +129,14 @@ func (t *task) Run(ctx context.Context, param Parameter) (err error) {
 
 	select {
 	case <-ctx.Done():
-		return NewError(ContextCanceled, "task: context has been canceled")
+		return canceledError(ctx, "task: context has been canceled")
 	default:
 	}
 
+	if t.clock != nil {
+		ctx = ContextWithClock(ctx, t.clock)
+	}
+
 	t.state = Running
 	defer func() {
 		// SPECIAL: result should be cloned param because it's just for this task
@@ -209,3 +231,10 @@ func SetPreStop(tr TaskRun) TaskOption {
 		t.preStop = tr
 	}
 }
+
+// SetTaskClock of task, which is passed to its functions by context, see ClockOf
+func SetTaskClock(c Clock) TaskOption {
+	return func(t *task) {
+		t.clock = c
+	}
+}
@@ -16,6 +16,7 @@ package tao
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -49,7 +50,9 @@ func TestNewTask(t *testing.T) {
 
 		ctx, cancel := context.WithCancel(context.Background())
 		cancel()
-		assert.Equal(t, ContextCanceled, taskError.Run(ctx, NewParameter()).(ErrorTao).Code())
+		err := taskError.Run(ctx, NewParameter())
+		assert.Equal(t, ContextCanceled, err.(ErrorTao).Code())
+		assert.True(t, errors.Is(err, context.Canceled))
 
 		assert.Equal(t, "I", taskError.Run(context.Background(), NewParameter()).(ErrorTao).Code())
 	})